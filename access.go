@@ -22,10 +22,7 @@ type ListKnownIPsBody struct {
 	IPs []KnownIP `json:"ips"`
 }
 
-type ListKnownIPsResponse struct {
-	Body ListKnownIPsBody `json:"body"`
-	Response
-}
+type ListKnownIPsResponse = Envelope[ListKnownIPsBody]
 
 type ListKnownIPsParams struct {
 	DeviceID string `json:"device_id"`
@@ -36,27 +33,19 @@ type LearnNewIPsParams struct {
 	IPs      []net.IP `json:"ips"`
 }
 
-type LearnNewIPsResponse struct {
-	Body []any `json:"body"`
-	Response
-	Message string `json:"message"`
-}
+type LearnNewIPsResponse = Envelope[[]any]
 
 type DeleteLearnedIPsParams struct {
 	DeviceID string   `json:"device_id"`
 	IPs      []net.IP `json:"ips"`
 }
 
-type DeleteLearnedIPsResponse struct {
-	Body []any `json:"body"`
-	Response
-	Message string `json:"message"`
-}
+type DeleteLearnedIPsResponse = Envelope[[]any]
 
-func (api *API) ListKnownIPs(ctx context.Context, params ListKnownIPsParams) ([]KnownIP, error) {
+func (api *API) ListKnownIPs(ctx context.Context, params ListKnownIPsParams, opts ...ReqOption) ([]KnownIP, error) {
 	uri := buildURI("/access", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, params, opts...)
 	if err != nil {
 		return []KnownIP{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -67,36 +56,32 @@ func (api *API) ListKnownIPs(ctx context.Context, params ListKnownIPsParams) ([]
 	return r.Body.IPs, nil
 }
 
-func (api *API) LearnNewIPs(ctx context.Context, params LearnNewIPsParams) ([]any, error) {
+func (api *API) LearnNewIPs(ctx context.Context, params LearnNewIPsParams, opts ...ReqOption) (UpdateResult, error) {
 	uri := buildURI("/access", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params, opts...)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return UpdateResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r LearnNewIPsResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[json.RawMessage](res)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return UpdateResult{}, err
 	}
-	return r.Body, nil
+	return UpdateResult{Message: r.Message}, nil
 }
 
-func (api *API) DeleteLearnedIPs(ctx context.Context, params DeleteLearnedIPsParams) ([]any, error) {
+func (api *API) DeleteLearnedIPs(ctx context.Context, params DeleteLearnedIPsParams, opts ...ReqOption) (DeleteResult, error) {
 	uri := buildURI("/access", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params, opts...)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return DeleteResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r DeleteLearnedIPsResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[json.RawMessage](res)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return DeleteResult{}, err
 	}
-	return r.Body, nil
+	return DeleteResult{Message: r.Message}, nil
 }
@@ -74,7 +74,7 @@ func TestLearnNewIPs(t *testing.T) {
 		IPs:      []net.IP{net.ParseIP("114.157.113.63")},
 	})
 
-	want := []any{}
+	want := UpdateResult{Message: "1 IPs added"}
 	if assert.NoError(t, err) {
 		assert.Equal(t, want, actual)
 	}
@@ -101,7 +101,7 @@ func TestDeleteLearnedIPs(t *testing.T) {
 		IPs:      []net.IP{net.ParseIP("114.157.113.63")},
 	})
 
-	want := []any{}
+	want := DeleteResult{Message: "1 IPs deleted"}
 	if assert.NoError(t, err) {
 		assert.Equal(t, want, actual)
 	}
@@ -6,27 +6,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"time"
 )
 
-type Date struct {
-	time.Time
-}
-
-func (s Date) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s.Format(time.DateOnly))
-}
-
-func (s *Date) UnmarshalJSON(data []byte) error {
-	dateStr := string(data[1 : len(data)-1])
-	date, err := time.Parse(time.DateOnly, dateStr)
-	if err != nil {
-		return err
-	}
-	s.Time = date
-	return nil
-}
-
 type User struct {
 	PK             string   `json:"PK"`
 	ResolverIP     net.IP   `json:"resolver_ip"`
@@ -46,15 +27,12 @@ type User struct {
 	Debug          []any    `json:"debug"`
 }
 
-type ListUserResponse struct {
-	Body User `json:"body"`
-	Response
-}
+type ListUserResponse = Envelope[User]
 
-func (api *API) ListUser(ctx context.Context) (User, error) {
+func (api *API) ListUser(ctx context.Context, opts ...ReqOption) (User, error) {
 	uri := buildURI("/users", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return User{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
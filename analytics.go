@@ -16,10 +16,7 @@ type ListLogLevelsBody struct {
 	Levels []LogLevel `json:"levels"`
 }
 
-type ListLogLevelsResponse struct {
-	Body ListLogLevelsBody `json:"body"`
-	Response
-}
+type ListLogLevelsResponse = Envelope[ListLogLevelsBody]
 
 type Endpoint struct {
 	PK          string `json:"PK"`
@@ -31,15 +28,12 @@ type ListStorageRegionsBody struct {
 	Endpoint []Endpoint `json:"endpoints"`
 }
 
-type ListStorageRegionsResponse struct {
-	Body ListStorageRegionsBody `json:"body"`
-	Response
-}
+type ListStorageRegionsResponse = Envelope[ListStorageRegionsBody]
 
-func (api *API) ListLogLevels(ctx context.Context) ([]LogLevel, error) {
+func (api *API) ListLogLevels(ctx context.Context, opts ...ReqOption) ([]LogLevel, error) {
 	uri := buildURI("/analytics/levels", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []LogLevel{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -53,10 +47,10 @@ func (api *API) ListLogLevels(ctx context.Context) ([]LogLevel, error) {
 	return r.Body.Levels, nil
 }
 
-func (api *API) ListStorageRegions(ctx context.Context) ([]Endpoint, error) {
+func (api *API) ListStorageRegions(ctx context.Context, opts ...ReqOption) ([]Endpoint, error) {
 	uri := buildURI("/analytics/endpoints", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Endpoint{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
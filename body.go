@@ -0,0 +1,111 @@
+package controld
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBodyNotReplayable is returned when a request has to be retried but its
+// body was streamed and cannot be sent again.
+var ErrBodyNotReplayable = errors.New("request body cannot be replayed")
+
+// ReplayableBody can be passed as request params when the body should be
+// produced afresh for every attempt, e.g. by re-opening a file. GetBody follows
+// the semantics of http.Request.GetBody.
+type ReplayableBody interface {
+	GetBody() (io.ReadCloser, error)
+}
+
+// requestBody produces the body of every attempt made for a single request.
+type requestBody func() (io.Reader, error)
+
+// newRequestBody prepares params so that they can be sent once per attempt.
+// Readers which are neither seekable nor a ReplayableBody are buffered in
+// memory up to maxSize bytes; larger bodies are streamed and can only be sent
+// once.
+func newRequestBody(params interface{}, maxSize int64) (requestBody, error) {
+	switch p := params.(type) {
+	case nil:
+		return func() (io.Reader, error) { return nil, nil }, nil
+	case ReplayableBody:
+		return func() (io.Reader, error) {
+			r, err := p.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("could not replay request body: %w", err)
+			}
+			return r, nil
+		}, nil
+	case []byte:
+		return bytesBody(p), nil
+	case io.ReadSeeker:
+		offset, err := p.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("could not read request body position: %w", err)
+		}
+		return func() (io.Reader, error) {
+			if _, err := p.Seek(offset, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("could not rewind request body: %w", err)
+			}
+			// the transport closes the body it sends, which would prevent
+			// rewinding e.g. an *os.File for the next attempt
+			if _, ok := p.(io.Closer); ok {
+				return io.NopCloser(p), nil
+			}
+			return p, nil
+		}, nil
+	case io.Reader:
+		// the reader would have been closed by the transport if it was sent
+		// as is: close it once buffered, or along with the streamed body
+		closer, _ := p.(io.Closer)
+		buf, err := io.ReadAll(io.LimitReader(p, maxSize+1))
+		if err != nil {
+			if closer != nil {
+				_ = closer.Close()
+			}
+			return nil, fmt.Errorf("could not read request body: %w", err)
+		}
+		if int64(len(buf)) <= maxSize {
+			if closer != nil {
+				_ = closer.Close()
+			}
+			return bytesBody(buf), nil
+		}
+		var r io.Reader = io.MultiReader(bytes.NewReader(buf), p)
+		if closer != nil {
+			r = struct {
+				io.Reader
+				io.Closer
+			}{r, closer}
+		}
+		return streamedBody(r, maxSize), nil
+	default:
+		// encoding/json rather than go-json, which ignores the omitzero
+		// option used by Optional fields
+		jsonBody, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling params to JSON: %w", err)
+		}
+		return bytesBody(jsonBody), nil
+	}
+}
+
+func bytesBody(b []byte) requestBody {
+	return func() (io.Reader, error) {
+		return bytes.NewReader(b), nil
+	}
+}
+
+// streamedBody can only be read by the first attempt.
+func streamedBody(r io.Reader, maxSize int64) requestBody {
+	sent := false
+	return func() (io.Reader, error) {
+		if sent {
+			return nil, fmt.Errorf("%w: body is larger than %d bytes, use a ReplayableBody or an io.ReadSeeker", ErrBodyNotReplayable, maxSize)
+		}
+		sent = true
+		return r, nil
+	}
+}
@@ -0,0 +1,213 @@
+package controld
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// onceReader hides the io.Seeker implementation of the wrapped reader.
+type onceReader struct {
+	io.Reader
+}
+
+type replayable string
+
+func (r replayable) GetBody() (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(r))), nil
+}
+
+func TestNewRequestBodyReplays(t *testing.T) {
+	tests := []struct {
+		name   string
+		params interface{}
+		want   string
+	}{
+		{"bytes", []byte(`{"name":"bytes"}`), `{"name":"bytes"}`},
+		{"seeker", strings.NewReader(`{"name":"seeker"}`), `{"name":"seeker"}`},
+		{"reader", onceReader{strings.NewReader(`{"name":"reader"}`)}, `{"name":"reader"}`},
+		{"replayable", replayable(`{"name":"replayable"}`), `{"name":"replayable"}`},
+		{"json", CreateProfileParams{Name: "json"}, `{"name":"json","clone_profile_id":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := newRequestBody(tt.params, 1024)
+			require.NoError(t, err)
+			for attempt := 0; attempt < 3; attempt++ {
+				r, err := body()
+				require.NoError(t, err)
+				b, err := io.ReadAll(r)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, string(b), "attempt %d", attempt)
+			}
+		})
+	}
+}
+
+func TestNewRequestBodyTooLargeToReplay(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 64)
+	body, err := newRequestBody(onceReader{bytes.NewReader(payload)}, 16)
+	require.NoError(t, err)
+
+	r, err := body()
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, payload, b, "first attempt should stream the whole body")
+
+	_, err = body()
+	assert.ErrorIs(t, err, ErrBodyNotReplayable)
+}
+
+func TestRawRetriesStreamedBody(t *testing.T) {
+	setup(UsingRetryPolicy(1, 0, 0))
+	defer teardown()
+
+	var bodies []string
+	mux.HandleFunc("/profiles/PK", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {}, "success": true}`)
+	})
+
+	payload := `{"name":"streamed"}`
+	_, err := client.Raw(context.Background(), http.MethodPut, "/profiles/PK", onceReader{strings.NewReader(payload)}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{payload, payload}, bodies)
+}
+
+func TestRawFailsWhenBodyCannotBeReplayed(t *testing.T) {
+	setup(UsingRetryPolicy(1, 0, 0), UsingMaxReplayBodySize(4))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/profiles/PK", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := client.Raw(context.Background(), http.MethodPut, "/profiles/PK", onceReader{strings.NewReader(`{"name":"streamed"}`)}, nil)
+	require.ErrorIs(t, err, ErrBodyNotReplayable)
+	assert.ErrorIs(t, err, ErrServiceError, "the error of the last response should be kept")
+	var serviceErr *ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, http.StatusBadGateway, serviceErr.StatusCode())
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRawRetriesFileBody(t *testing.T) {
+	setup(UsingRetryPolicy(1, 0, 0))
+	defer teardown()
+
+	var bodies []string
+	mux.HandleFunc("/profiles/PK", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {}, "success": true}`)
+	})
+
+	payload := `{"name":"file"}`
+	path := filepath.Join(t.TempDir(), "profile.json")
+	require.NoError(t, os.WriteFile(path, []byte(payload), 0o600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = client.Raw(context.Background(), http.MethodPut, "/profiles/PK", f, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{payload, payload}, bodies)
+
+	// the file is still the caller's to close
+	_, err = f.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+}
+
+// trackedBody counts the bodies it opens and closes.
+type trackedBody struct {
+	opened, closed int
+}
+
+func (b *trackedBody) GetBody() (io.ReadCloser, error) {
+	b.opened++
+	return &trackedReader{Reader: strings.NewReader(`{}`), body: b}, nil
+}
+
+type trackedReader struct {
+	io.Reader
+	body *trackedBody
+}
+
+func (r *trackedReader) Close() error {
+	r.body.closed++
+	return nil
+}
+
+func TestAbortedAttemptClosesBody(t *testing.T) {
+	setup(UsingCircuitBreaker(CircuitBreakerSettings{MinRequests: 1, OpenTimeout: time.Minute}))
+	defer teardown()
+
+	mux.HandleFunc("/profiles/PK", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	body := &trackedBody{}
+	_, err := client.Raw(context.Background(), http.MethodPut, "/profiles/PK", body, nil)
+	assert.ErrorIs(t, err, ErrServiceError)
+	require.Equal(t, CircuitOpen, client.CircuitState())
+
+	_, err = client.Raw(context.Background(), http.MethodPut, "/profiles/PK", body, nil)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, body.opened, body.closed)
+}
+
+// closeTracker hides the io.Seeker implementation of the wrapped reader and
+// records whether it was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeTracker) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestNewRequestBodyClosesReaders(t *testing.T) {
+	buffered := &closeTracker{Reader: strings.NewReader(`{"name":"buffered"}`)}
+	_, err := newRequestBody(buffered, 1024)
+	require.NoError(t, err)
+	assert.True(t, buffered.closed, "a buffered reader should be closed")
+
+	streamed := &closeTracker{Reader: bytes.NewReader(bytes.Repeat([]byte("a"), 64))}
+	body, err := newRequestBody(streamed, 16)
+	require.NoError(t, err)
+	assert.False(t, streamed.closed, "a streamed reader is still to be read")
+
+	r, err := body()
+	require.NoError(t, err)
+	closer, ok := r.(io.Closer)
+	require.True(t, ok, "the streamed body should close the reader")
+	require.NoError(t, closer.Close())
+	assert.True(t, streamed.closed)
+}
@@ -0,0 +1,117 @@
+package controld
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses of catalogue endpoints. Implementations must be
+// safe for concurrent use; MemoryCache is provided, other backends such as a
+// disk cache can be plugged in with UsingCache.
+type Cache interface {
+	// Get returns the value stored under key, if it has not expired.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value under key for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+
+	// DeletePrefix removes every value whose key starts with prefix.
+	DeletePrefix(prefix string)
+}
+
+// cacheableRoutes are the routes of the near-static catalogue endpoints whose
+// responses are cached.
+var cacheableRoutes = map[string]bool{
+	"/analytics/endpoints":      true,
+	"/analytics/levels":         true,
+	"/devices/types":            true,
+	"/network":                  true,
+	"/profiles/options":         true,
+	"/services/categories":      true,
+	"/services/categories/{id}": true,
+}
+
+// cacheKey returns the key under which the response of uri is cached.
+// Responses are cached per impersonated organization.
+func (api *API) cacheKey(uri string, headers http.Header) string {
+	key := api.BaseURL + uri
+	if orgID := headers.Get(organizationHeader); orgID != "" {
+		key += "#" + orgID
+	}
+	return key
+}
+
+// cacheInvalidationPrefix returns the prefix of the cached responses made
+// stale by a mutating request to uri: every response of the same top-level
+// resource.
+func (api *API) cacheInvalidationPrefix(uri string) string {
+	resource := strings.TrimPrefix(uri, "/")
+	if i := strings.IndexAny(resource, "/?"); i >= 0 {
+		resource = resource[:i]
+	}
+	return api.BaseURL + "/" + resource
+}
+
+// isCacheable reports whether the response of a request can be served from
+// the cache.
+func isCacheable(method, uri string) bool {
+	return method == http.MethodGet && cacheableRoutes[routeTemplate(uri)]
+}
+
+// MemoryCache is an in-memory Cache.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	now     func() time.Time
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = memoryCacheEntry{value: value, expires: c.now().Add(ttl)}
+}
+
+// DeletePrefix implements Cache.
+func (c *MemoryCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
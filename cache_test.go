@@ -0,0 +1,83 @@
+package controld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache(t *testing.T) {
+	now := time.Unix(1716000000, 0)
+	cache := NewMemoryCache()
+	cache.now = func() time.Time { return now }
+
+	cache.Set("https://api.controld.com/services/categories", []byte("categories"), time.Minute)
+	cache.Set("https://api.controld.com/services/categories/audio", []byte("audio"), time.Minute)
+	cache.Set("https://api.controld.com/devices/types", []byte("types"), time.Minute)
+
+	value, ok := cache.Get("https://api.controld.com/services/categories")
+	require.True(t, ok)
+	assert.Equal(t, []byte("categories"), value)
+
+	cache.DeletePrefix("https://api.controld.com/services")
+	_, ok = cache.Get("https://api.controld.com/services/categories")
+	assert.False(t, ok)
+	_, ok = cache.Get("https://api.controld.com/services/categories/audio")
+	assert.False(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get("https://api.controld.com/devices/types")
+	assert.False(t, ok, "entry should have expired")
+}
+
+func TestIsCacheable(t *testing.T) {
+	assert.True(t, isCacheable(http.MethodGet, "/services/categories"))
+	assert.True(t, isCacheable(http.MethodGet, "/services/categories/audio"))
+	assert.True(t, isCacheable(http.MethodGet, "/profiles/options"))
+	assert.False(t, isCacheable(http.MethodGet, "/profiles"))
+	assert.False(t, isCacheable(http.MethodGet, "/devices"))
+	assert.False(t, isCacheable(http.MethodPut, "/profiles/options"))
+}
+
+func TestUsingCache(t *testing.T) {
+	setup(UsingCache(NewMemoryCache(), time.Hour))
+	defer teardown()
+
+	optionsCalls := 0
+	mux.HandleFunc("/profiles/options", func(w http.ResponseWriter, r *http.Request) {
+		optionsCalls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"options": [{"PK": "ai_malware", "type": "field"}]}, "success": true}`)
+	})
+	profilesCalls := 0
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		profilesCalls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	for i := 0; i < 3; i++ {
+		options, err := client.ListProfilesOptions(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, options, 1)
+	}
+	assert.Equal(t, 1, optionsCalls, "catalogue responses should be cached")
+
+	for i := 0; i < 2; i++ {
+		_, err := client.ListProfiles(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, profilesCalls, "non catalogue responses should not be cached")
+
+	_, err := client.CreateProfile(context.Background(), CreateProfileParams{Name: "profile"})
+	require.NoError(t, err)
+
+	_, err = client.ListProfilesOptions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, optionsCalls, "mutating the resource should invalidate the cache")
+}
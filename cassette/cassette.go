@@ -0,0 +1,363 @@
+// Package cassette provides an http.RoundTripper recording the exchanges of a
+// client with the Control D API into cassette files, and replaying them
+// offline. It is meant to refresh test fixtures and to run integration tests
+// without network access:
+//
+//	rec, err := cassette.New("testdata/cassettes/devices.json", cassette.ModeRecord)
+//	...
+//	defer rec.Save()
+//	api, err := controld.New(token, controld.HTTPClient(rec.Client()))
+//
+// Recorded requests never hold the API token: the Authorization header is
+// redacted, as are password fields and any secret given with WithSecrets.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// ErrInteractionNotFound is returned in replay mode for requests which were
+// not recorded in the cassette.
+var ErrInteractionNotFound = errors.New("cassette: no recorded interaction matches the request")
+
+var passwordFieldRegexp = regexp.MustCompile(`("(?i:password)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// Mode selects whether a Recorder sends requests or replays them.
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette, without network access.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests and records the exchanges in the cassette.
+	ModeRecord
+)
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. URL only holds the path and query, so that a
+// cassette can be replayed against any base URL.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is a recorded body. JSON bodies are kept as is in the cassette file so
+// that it can be read and edited, other bodies as a JSON string.
+type Body []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b Body) MarshalJSON() ([]byte, error) {
+	if len(b) == 0 {
+		return []byte("null"), nil
+	}
+	if json.Valid(b) && !bytes.HasPrefix(bytes.TrimSpace(b), []byte(`"`)) {
+		return b, nil
+	}
+	return json.Marshal(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Body) UnmarshalJSON(data []byte) error {
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*b = nil
+	case bytes.HasPrefix(data, []byte(`"`)):
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*b = Body(s)
+	default:
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, data); err != nil {
+			return err
+		}
+		*b = compact.Bytes()
+	}
+	return nil
+}
+
+// Option is a functional option for configuring a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to send requests in record mode.
+// Defaults to http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithSecrets redacts secrets, e.g. the API token or the names of real
+// devices, from recorded URLs, headers and bodies.
+func WithSecrets(secrets ...string) Option {
+	return func(r *Recorder) {
+		r.secrets = append(r.secrets, secrets...)
+	}
+}
+
+// Recorder is an http.RoundTripper recording or replaying a cassette. It is
+// safe for concurrent use.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	secrets   []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+// New returns a Recorder for the cassette file at path. In replay mode the
+// cassette is loaded from path; in record mode it is written to path by Save.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: could not read %s: %w", path, err)
+		}
+		if err := json.Unmarshal(b, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: could not parse %s: %w", path, err)
+		}
+		r.replayed = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Client returns an *http.Client using the Recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the interactions of the cassette.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, out, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{
+		Method:  req.Method,
+		URL:     r.redact(req.URL.RequestURI()),
+		Headers: r.redactHeader(req.Header),
+		Body:    Body(r.redact(string(reqBody))),
+	}
+
+	if r.mode == ModeReplay {
+		if out.Body != nil {
+			out.Body.Close()
+		}
+		return r.replay(req, recorded)
+	}
+
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: could not read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := r.redactHeader(resp.Header)
+	header.Del("Set-Cookie")
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    header,
+			Body:       Body(r.redact(string(respBody))),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay serves req from the first interaction matching it which was not
+// replayed yet, or from the last matching one once all were.
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !matches(interaction.Request, recorded) {
+			continue
+		}
+		found = i
+		if !r.replayed[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, recorded.Method, recorded.URL)
+	}
+	r.replayed[found] = true
+
+	recordedResp := r.cassette.Interactions[found].Response
+	header := recordedResp.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(recordedResp.StatusCode) + " " + http.StatusText(recordedResp.StatusCode),
+		StatusCode:    recordedResp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(recordedResp.Body)),
+		ContentLength: int64(len(recordedResp.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes the recorded interactions to the cassette file, creating its
+// directory if needed. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("cassette: could not encode: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("cassette: could not create directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("cassette: could not write %s: %w", r.path, err)
+	}
+	return nil
+}
+
+// matches reports whether a recorded request matches an incoming one. JSON
+// bodies are compared regardless of formatting.
+func matches(recorded, incoming Request) bool {
+	if recorded.Method != incoming.Method || recorded.URL != incoming.URL {
+		return false
+	}
+	return bytes.Equal(compactJSON(recorded.Body), compactJSON(incoming.Body))
+}
+
+func compactJSON(b []byte) []byte {
+	var compact bytes.Buffer
+	if json.Compact(&compact, b) != nil {
+		return b
+	}
+	return compact.Bytes()
+}
+
+// readRequestBody reads the body of req and returns the request to send to
+// the transport, which is req itself unless its body had to be consumed.
+func readRequestBody(req *http.Request) ([]byte, *http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, req, nil
+	}
+	// a RoundTripper must not modify the request: read a copy of the body
+	// when possible, otherwise send a clone with the buffered body
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, fmt.Errorf("cassette: could not read request body: %w", err)
+		}
+		b, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("cassette: could not read request body: %w", err)
+		}
+		return b, req, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cassette: could not read request body: %w", err)
+	}
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(b))
+	return b, out, nil
+}
+
+// redact removes the secrets and password fields from s.
+func (r *Recorder) redact(s string) string {
+	s = passwordFieldRegexp.ReplaceAllString(s, `${1}"`+redacted+`"`)
+	for _, secret := range r.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
+
+// redactHeader returns a copy of h with the Authorization header and secrets
+// redacted.
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	redactedHeader := make(http.Header, len(h))
+	for key, values := range h {
+		for _, value := range values {
+			if http.CanonicalHeaderKey(key) == "Authorization" {
+				value = redacted
+			}
+			redactedHeader.Add(key, r.redact(value))
+		}
+	}
+	return redactedHeader
+}
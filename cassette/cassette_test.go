@@ -0,0 +1,181 @@
+package cassette
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	controld "github.com/baptistecdr/controld-go"
+)
+
+const token = "api.1377"
+
+func TestRecordAndReplay(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	profileName := "Kids"
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session="+token)
+		fmt.Fprintf(w, `{"body": {"profiles": [{"PK": "PK1", "name": %q}]}, "success": true}`, profileName)
+	})
+	mux.HandleFunc("/profiles/PK1", func(w http.ResponseWriter, r *http.Request) {
+		profileName = "Teens"
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"body": {"profiles": [{"PK": "PK1", "name": %q}]}, "success": true}`, profileName)
+	})
+
+	path := filepath.Join(t.TempDir(), "profiles", "cassette.json")
+	ctx := context.Background()
+
+	rec, err := New(path, ModeRecord, WithSecrets(token))
+	require.NoError(t, err)
+	api, err := controld.New(token, controld.HTTPClient(rec.Client()), controld.BaseURL(server.URL), controld.UsingRateLimit(1000), controld.UsingRetryPolicy(0, 0, 0))
+	require.NoError(t, err)
+
+	before, err := api.ListProfiles(ctx)
+	require.NoError(t, err)
+	password := "s3cret"
+	params := controld.UpdateProfileParams{ProfileID: "PK1", Name: controld.Set("Teens"), Password: controld.Set(password)}
+	_, err = api.UpdateProfile(ctx, params)
+	require.NoError(t, err)
+	after, err := api.ListProfiles(ctx)
+	require.NoError(t, err)
+	require.NoError(t, rec.Save())
+	server.Close()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), token)
+	assert.NotContains(t, string(b), password)
+	assert.NotContains(t, string(b), "Set-Cookie")
+	assert.Contains(t, string(b), `"Authorization": [`+"\n"+`            "[REDACTED]"`)
+	assert.Contains(t, string(b), `"name": "Kids"`)
+
+	// the server is gone, responses come from the cassette
+	rec, err = New(path, ModeReplay)
+	require.NoError(t, err)
+	assert.Len(t, rec.Interactions(), 3)
+	api, err = controld.New("api.other", controld.HTTPClient(rec.Client()), controld.BaseURL("http://controld.invalid"), controld.UsingRateLimit(1000), controld.UsingRetryPolicy(0, 0, 0))
+	require.NoError(t, err)
+
+	replayed, err := api.ListProfiles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, before, replayed)
+	_, err = api.UpdateProfile(ctx, params)
+	require.NoError(t, err)
+	replayed, err = api.ListProfiles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, after, replayed)
+
+	// interactions are reused once all were replayed
+	replayed, err = api.ListProfiles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, after, replayed)
+
+	_, err = api.ListDevices(ctx)
+	assert.ErrorIs(t, err, ErrInteractionNotFound)
+}
+
+func TestReplayMatchesBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "interactions": [
+    {
+      "request": {"method": "POST", "url": "/echo", "body": {"value": 1}},
+      "response": {"status_code": 200, "body": "one"}
+    },
+    {
+      "request": {"method": "POST", "url": "/echo", "body": {"value": 2}},
+      "response": {"status_code": 201, "body": {"value": 2}}
+    }
+  ]
+}`), 0o644))
+
+	rec, err := New(path, ModeReplay)
+	require.NoError(t, err)
+	client := rec.Client()
+
+	resp, err := client.Post("http://controld.invalid/echo", "application/json", strings.NewReader(`{ "value": 2 }`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "201 Created", resp.Status)
+
+	resp, err = client.Post("http://controld.invalid/echo", "application/json", strings.NewReader(`{"value":1}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body := make([]byte, 3)
+	_, err = resp.Body.Read(body)
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(body))
+
+	_, err = client.Post("http://controld.invalid/echo", "application/json", strings.NewReader(`{"value":3}`))
+	assert.ErrorIs(t, err, ErrInteractionNotFound)
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+	assert.Error(t, err)
+}
+
+func TestBodyJSON(t *testing.T) {
+	for _, body := range []string{`{"a":1}`, `plain text`, `"quoted"`, `[1,2]`} {
+		b, err := Body(body).MarshalJSON()
+		require.NoError(t, err)
+		var decoded Body
+		require.NoError(t, decoded.UnmarshalJSON(b))
+		assert.Equal(t, body, string(decoded))
+	}
+}
+
+// transportFunc adapts a function to http.RoundTripper.
+type transportFunc func(*http.Request) (*http.Response, error)
+
+func (f transportFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecordLeavesRequestUntouched(t *testing.T) {
+	var sent []string
+	transport := transportFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		sent = append(sent, string(b))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{}`)), Request: req}, nil
+	})
+	rec, err := New(filepath.Join(t.TempDir(), "cassette.json"), ModeRecord, WithTransport(transport))
+	require.NoError(t, err)
+
+	for _, body := range []io.Reader{
+		strings.NewReader(`{"value":1}`),
+		// without GetBody
+		io.MultiReader(strings.NewReader(`{"value":2}`)),
+	} {
+		req, err := http.NewRequest(http.MethodPost, "http://controld.invalid/echo", body)
+		require.NoError(t, err)
+		original := req.Body
+		resp, err := rec.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.True(t, original == req.Body, "the request should not be modified")
+	}
+
+	assert.Equal(t, []string{`{"value":1}`, `{"value":2}`}, sent)
+	interactions := rec.Interactions()
+	require.Len(t, interactions, 2)
+	assert.Equal(t, Body(`{"value":2}`), interactions[1].Request.Body)
+}
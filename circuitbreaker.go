@@ -0,0 +1,244 @@
+package controld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while the circuit
+// breaker of the client is open.
+var ErrCircuitOpen error = circuitOpenError{}
+
+type circuitOpenError struct{}
+
+func (circuitOpenError) Error() string {
+	return "circuit breaker is open"
+}
+
+func (circuitOpenError) Type() ErrorType {
+	return ErrorTypeCircuitOpen
+}
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every request with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a few probe requests through to find out whether
+	// the API recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerSettings configures the circuit breaker enabled with
+// UsingCircuitBreaker. Zero fields take their default value.
+type CircuitBreakerSettings struct {
+	// FailureRate is the share of failed attempts, between 0 and 1, which
+	// opens the circuit. Attempts fail on HTTP 5xx responses, timeouts and
+	// network errors. Defaults to 0.5.
+	FailureRate float64
+
+	// MinRequests is the number of attempts made in the window before the
+	// failure rate is considered. Defaults to 10.
+	MinRequests int
+
+	// Window is the period over which the failure rate is measured. Defaults
+	// to one minute.
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before probe requests
+	// are let through. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of successful probe requests which close
+	// the circuit again. Defaults to 1.
+	HalfOpenRequests int
+}
+
+// circuitTransition is a change of state of a circuit breaker.
+type circuitTransition struct {
+	from, to CircuitState
+}
+
+// circuitBreaker fails requests fast while the API is degraded. It counts
+// attempts, so that retries of a failing request count as failures too.
+type circuitBreaker struct {
+	settings CircuitBreakerSettings
+	now      func() time.Time
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+func newCircuitBreaker(settings CircuitBreakerSettings) (*circuitBreaker, error) {
+	if settings.FailureRate < 0 || settings.FailureRate > 1 {
+		return nil, errors.New("circuit breaker failure rate must be between 0 and 1")
+	}
+	if settings.FailureRate == 0 {
+		settings.FailureRate = 0.5
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = 10
+	}
+	if settings.Window <= 0 {
+		settings.Window = time.Minute
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	return &circuitBreaker{settings: settings, now: time.Now}, nil
+}
+
+// State returns the current state of the breaker.
+func (cb *circuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.state
+}
+
+// allow reports whether an attempt may be made. Every allowed attempt must be
+// followed by a call to record or abandon.
+func (cb *circuitBreaker) allow() (*circuitTransition, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	var transition *circuitTransition
+	if cb.state == CircuitOpen {
+		if cb.now().Sub(cb.openedAt) < cb.settings.OpenTimeout {
+			return nil, ErrCircuitOpen
+		}
+		transition = cb.setState(CircuitHalfOpen)
+	}
+	if cb.state == CircuitHalfOpen {
+		if cb.probes+cb.successes >= cb.settings.HalfOpenRequests {
+			return transition, ErrCircuitOpen
+		}
+		cb.probes++
+	}
+	return transition, nil
+}
+
+// record accounts for the outcome of an allowed attempt.
+func (cb *circuitBreaker) record(failed bool) *circuitTransition {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		if cb.probes > 0 {
+			cb.probes--
+		}
+		if failed {
+			return cb.setState(CircuitOpen)
+		}
+		cb.successes++
+		if cb.successes >= cb.settings.HalfOpenRequests {
+			return cb.setState(CircuitClosed)
+		}
+	case CircuitClosed:
+		now := cb.now()
+		if cb.windowStart.IsZero() || !now.Before(cb.windowStart.Add(cb.settings.Window)) {
+			cb.windowStart = now
+			cb.requests, cb.failures = 0, 0
+		}
+		cb.requests++
+		if failed {
+			cb.failures++
+		}
+		if cb.requests >= cb.settings.MinRequests &&
+			float64(cb.failures)/float64(cb.requests) >= cb.settings.FailureRate {
+			return cb.setState(CircuitOpen)
+		}
+	}
+	return nil
+}
+
+// abandon releases an allowed attempt which did not reach the API, e.g.
+// because its context was canceled.
+func (cb *circuitBreaker) abandon() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// setState moves the breaker to state. cb.mu must be held.
+func (cb *circuitBreaker) setState(state CircuitState) *circuitTransition {
+	transition := &circuitTransition{from: cb.state, to: state}
+	cb.state = state
+	cb.probes, cb.successes = 0, 0
+	cb.requests, cb.failures = 0, 0
+	cb.windowStart = time.Time{}
+	if state == CircuitOpen {
+		cb.openedAt = cb.now()
+	}
+	return transition
+}
+
+// isCircuitFailure reports whether the outcome of an attempt indicates that
+// the API is degraded.
+func isCircuitFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		// the HTTP client reports network errors as *url.Error
+		var urlErr *url.Error
+		return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &urlErr)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// logCircuitTransition reports a change of state of the circuit breaker.
+func (api *API) logCircuitTransition(ctx context.Context, transition *circuitTransition) {
+	if transition == nil {
+		return
+	}
+	level := slog.LevelInfo
+	if transition.to == CircuitOpen {
+		level = slog.LevelWarn
+	}
+	api.log(ctx, level, "circuit breaker "+transition.to.String(),
+		slog.String("from", transition.from.String()),
+		slog.String("to", transition.to.String()),
+	)
+}
+
+// CircuitState returns the state of the circuit breaker of the client,
+// CircuitClosed when it has none.
+func (api *API) CircuitState() CircuitState {
+	if api.breaker == nil {
+		return CircuitClosed
+	}
+	return api.breaker.State()
+}
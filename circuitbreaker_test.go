@@ -0,0 +1,216 @@
+package controld
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	cb, err := newCircuitBreaker(CircuitBreakerSettings{
+		FailureRate:      0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		OpenTimeout:      10 * time.Second,
+		HalfOpenRequests: 2,
+	})
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb.now = func() time.Time { return now }
+
+	// 1 failure out of 3 attempts stays below the threshold
+	for _, failed := range []bool{false, true, false} {
+		_, err := cb.allow()
+		require.NoError(t, err)
+		assert.Nil(t, cb.record(failed))
+	}
+
+	_, err = cb.allow()
+	require.NoError(t, err)
+	assert.Equal(t, &circuitTransition{from: CircuitClosed, to: CircuitOpen}, cb.record(true))
+
+	_, err = cb.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	now = now.Add(10 * time.Second)
+	transition, err := cb.allow()
+	require.NoError(t, err)
+	assert.Equal(t, &circuitTransition{from: CircuitOpen, to: CircuitHalfOpen}, transition)
+
+	// the second probe is allowed, a third one is not
+	_, err = cb.allow()
+	require.NoError(t, err)
+	_, err = cb.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	assert.Nil(t, cb.record(false))
+	assert.Equal(t, &circuitTransition{from: CircuitHalfOpen, to: CircuitClosed}, cb.record(false))
+	assert.Equal(t, CircuitClosed, cb.State())
+}
+
+func TestCircuitBreakerProbeFailureReopens(t *testing.T) {
+	cb, err := newCircuitBreaker(CircuitBreakerSettings{MinRequests: 1})
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb.now = func() time.Time { return now }
+
+	_, _ = cb.allow()
+	cb.record(true)
+	require.Equal(t, CircuitOpen, cb.State())
+
+	now = now.Add(30 * time.Second)
+	_, err = cb.allow()
+	require.NoError(t, err)
+	assert.Equal(t, &circuitTransition{from: CircuitHalfOpen, to: CircuitOpen}, cb.record(true))
+
+	_, err = cb.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	cb, err := newCircuitBreaker(CircuitBreakerSettings{MinRequests: 2, Window: time.Minute})
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb.now = func() time.Time { return now }
+
+	_, _ = cb.allow()
+	cb.record(true)
+	now = now.Add(time.Minute)
+	_, _ = cb.allow()
+	cb.record(false)
+
+	assert.Equal(t, CircuitClosed, cb.State())
+}
+
+func TestCircuitBreakerSettingsValidation(t *testing.T) {
+	_, err := New("api.1377", UsingCircuitBreaker(CircuitBreakerSettings{FailureRate: 1.5}))
+	assert.Error(t, err)
+}
+
+func TestUsingCircuitBreaker(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	setup(
+		UsingCircuitBreaker(CircuitBreakerSettings{MinRequests: 3, OpenTimeout: time.Minute}),
+		UsingRetryPolicy(1, 0, 0),
+		UsingSlog(logger),
+	)
+	defer teardown()
+
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	requests := 0
+	healthy := false
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"devices": []}, "success": true}`)
+	})
+
+	// two attempts, both failed
+	_, err := client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrServiceError)
+	assert.Equal(t, CircuitClosed, client.CircuitState())
+
+	// the first attempt opens the circuit, the retry fails fast
+	_, err = client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, CircuitOpen, client.CircuitState())
+	assert.Equal(t, 3, requests)
+	assert.Contains(t, buf.String(), `level=WARN msg="circuit breaker open"`)
+
+	_, err = client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, ErrorTypeCircuitOpen, errorTypeOf(err))
+	assert.Equal(t, 3, requests)
+
+	// derived clients share the breaker
+	derived, err := client.With(UserAgent("derived"))
+	require.NoError(t, err)
+	_, err = derived.ListDevices(context.Background())
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+	healthy = true
+	now = now.Add(time.Minute)
+	_, err = client.ListDevices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, CircuitClosed, client.CircuitState())
+	assert.Equal(t, 4, requests)
+	assert.Contains(t, buf.String(), `msg="circuit breaker half-open"`)
+	assert.Contains(t, buf.String(), `msg="circuit breaker closed"`)
+}
+
+// countingLimiter counts the calls to Wait.
+type countingLimiter struct {
+	waits int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return nil
+}
+
+func (l *countingLimiter) Observe(resp *http.Response) {}
+
+func (l *countingLimiter) Budget() RateLimitBudget {
+	return RateLimitBudget{}
+}
+
+func TestOpenCircuitSkipsRateLimiter(t *testing.T) {
+	limiter := &countingLimiter{}
+	setup(
+		UsingCircuitBreaker(CircuitBreakerSettings{MinRequests: 1, OpenTimeout: time.Minute}),
+		UsingRateLimiter(limiter),
+	)
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrServiceError)
+	require.Equal(t, CircuitOpen, client.CircuitState())
+	assert.Equal(t, 1, limiter.waits)
+
+	_, err = client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 1, limiter.waits, "an open circuit should fail before waiting on the rate limiter")
+}
+
+func TestCircuitOpeningMidRetryKeepsResponseError(t *testing.T) {
+	setup(
+		UsingCircuitBreaker(CircuitBreakerSettings{MinRequests: 1, OpenTimeout: time.Minute}),
+		UsingRetryPolicy(1, 0, 0),
+	)
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, `{"success": false, "error": {"message": "Upstream down", "code": 502}}`)
+	})
+
+	// the failed attempt opens the circuit, which aborts the retry
+	_, err := client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorIs(t, err, ErrServiceError)
+	var serviceErr *ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, http.StatusBadGateway, serviceErr.StatusCode())
+	assert.Equal(t, "Upstream down", serviceErr.Details().Error.Message)
+}
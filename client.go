@@ -0,0 +1,100 @@
+package controld
+
+import (
+	"context"
+	"net/http"
+)
+
+// AccessAPI lists and manages the IPs authorized to use a device.
+type AccessAPI interface {
+	ListKnownIPs(ctx context.Context, params ListKnownIPsParams, opts ...ReqOption) ([]KnownIP, error)
+	LearnNewIPs(ctx context.Context, params LearnNewIPsParams, opts ...ReqOption) (UpdateResult, error)
+	DeleteLearnedIPs(ctx context.Context, params DeleteLearnedIPsParams, opts ...ReqOption) (DeleteResult, error)
+}
+
+// AccountAPI reads the account of the API token.
+type AccountAPI interface {
+	ListUser(ctx context.Context, opts ...ReqOption) (User, error)
+}
+
+// AnalyticsAPI reads the analytics settings catalogue.
+type AnalyticsAPI interface {
+	ListLogLevels(ctx context.Context, opts ...ReqOption) ([]LogLevel, error)
+	ListStorageRegions(ctx context.Context, opts ...ReqOption) ([]Endpoint, error)
+}
+
+// DevicesAPI lists and manages devices.
+type DevicesAPI interface {
+	ListDevices(ctx context.Context, opts ...ReqOption) ([]Device, error)
+	CreateDevice(ctx context.Context, params CreateDeviceParams, opts ...ReqOption) (Device, error)
+	ListDeviceType(ctx context.Context, opts ...ReqOption) (DeviceTypes, error)
+	UpdateDevice(ctx context.Context, params UpdateDeviceParams, opts ...ReqOption) (Device, error)
+	DeleteDevice(ctx context.Context, params DeleteDeviceParams, opts ...ReqOption) (DeleteResult, error)
+}
+
+// NetworkAPI reads the network of Control D and the IP of the caller.
+type NetworkAPI interface {
+	ListIP(ctx context.Context, opts ...ReqOption) (IP, error)
+	ListNetwork(ctx context.Context, opts ...ReqOption) ([]Network, error)
+}
+
+// ProfilesAPI lists and manages profiles and their options.
+type ProfilesAPI interface {
+	ListProfiles(ctx context.Context, opts ...ReqOption) ([]Profile, error)
+	CreateProfile(ctx context.Context, params CreateProfileParams, opts ...ReqOption) ([]Profile, error)
+	UpdateProfile(ctx context.Context, params UpdateProfileParams, opts ...ReqOption) ([]Profile, error)
+	DeleteProfile(ctx context.Context, params DeleteProfileParams, opts ...ReqOption) (DeleteResult, error)
+	ListProfilesOptions(ctx context.Context, opts ...ReqOption) ([]ProfilesOption, error)
+	UpdateProfilesOption(ctx context.Context, params UpdateProfilesOption, opts ...ReqOption) (UpdateResult, error)
+}
+
+// RulesAPI manages the custom rules, rule folders and default rule of
+// profiles.
+type RulesAPI interface {
+	ListProfileCustomRules(ctx context.Context, params ListProfileCustomRulesParams, opts ...ReqOption) ([]Rule, error)
+	CreateProfileCustomRule(ctx context.Context, params CreateProfileCustomRuleParams, opts ...ReqOption) ([]CustomRule, error)
+	UpdateProfileCustomRule(ctx context.Context, params UpdateProfileCustomRuleParams, opts ...ReqOption) ([]CustomRule, error)
+	DeleteProfileCustomRule(ctx context.Context, params DeleteProfileCustomRuleParams, opts ...ReqOption) (DeleteResult, error)
+	ListProfileRuleFolders(ctx context.Context, params ListProfileRuleFoldersParams, opts ...ReqOption) ([]Group, error)
+	CreateProfileRuleFolder(ctx context.Context, params CreateProfileRuleFolderParams, opts ...ReqOption) ([]Group, error)
+	UpdateProfileRuleFolder(ctx context.Context, params UpdateProfileRuleFolderParams, opts ...ReqOption) ([]Group, error)
+	DeleteProfileRuleFolder(ctx context.Context, params DeleteProfileRuleFolderParams, opts ...ReqOption) (DeleteResult, error)
+	ListProfileDefaultRule(ctx context.Context, params ListProfileDefaultRuleParams, opts ...ReqOption) (DefaultRule, error)
+	UpdateProfileDefaultRule(ctx context.Context, params UpdateProfileDefaultRuleParams, opts ...ReqOption) (DefaultRule, error)
+}
+
+// ServicesAPI reads the services catalogue and manages the services of
+// profiles.
+type ServicesAPI interface {
+	ListServiceCategories(ctx context.Context, opts ...ReqOption) ([]Category, error)
+	ListServices(ctx context.Context, params ListServicesParams, opts ...ReqOption) ([]Service, error)
+	ListProfileServices(ctx context.Context, params ListProfileServicesParams, opts ...ReqOption) ([]ProfileService, error)
+	UpdateProfileService(ctx context.Context, params UpdateProfileServiceParams, opts ...ReqOption) ([]Action, error)
+}
+
+// FiltersAPI manages the filters of profiles.
+type FiltersAPI interface {
+	ListProfileNativeFilters(ctx context.Context, params ListProfileFiltersParams, opts ...ReqOption) ([]Filter, error)
+	ListProfileExternalFilters(ctx context.Context, params ListProfileFiltersParams, opts ...ReqOption) ([]Filter, error)
+	UpdateProfileFilter(ctx context.Context, params UpdateProfileFilterParams, opts ...ReqOption) (UpdateResult, error)
+}
+
+// Client covers every API call of the Control D client. *API implements it;
+// depend on Client, or on the narrower interfaces it embeds, to substitute
+// the client in tests or decorate it. controldtest.MockClient is a ready to
+// use mock.
+type Client interface {
+	AccessAPI
+	AccountAPI
+	AnalyticsAPI
+	DevicesAPI
+	NetworkAPI
+	ProfilesAPI
+	RulesAPI
+	ServicesAPI
+	FiltersAPI
+
+	Raw(ctx context.Context, method, endpoint string, data interface{}, headers http.Header, opts ...ReqOption) (RawResponse, error)
+}
+
+var _ Client = (*API)(nil)
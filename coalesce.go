@@ -0,0 +1,109 @@
+package controld
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// flightGroup shares a single in-flight call between concurrent identical
+// requests, in the manner of golang.org/x/sync/singleflight.
+type flightGroup struct {
+	mu        sync.Mutex
+	flights   map[string]*flight
+	coalesced atomic.Int64
+}
+
+// flight is a call in progress or completed.
+type flight struct {
+	done  chan struct{}
+	res   *APIResponse
+	err   error
+	stats callStats
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight)}
+}
+
+// do runs fn once for all the concurrent calls with the same key and returns
+// its result to each of them, reporting whether the result was shared with an
+// earlier caller. fn runs detached from the cancellation of the caller which
+// started it, but keeps its deadline, so that the other callers are not
+// affected if it gives up; each caller stops waiting when its own context is
+// done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context, stats *callStats) (*APIResponse, error)) (*APIResponse, callStats, bool, error) {
+	g.mu.Lock()
+	f, shared := g.flights[key]
+	if shared {
+		g.coalesced.Add(1)
+	} else {
+		f = &flight{done: make(chan struct{})}
+		g.flights[key] = f
+
+		flightCtx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok {
+			flightCtx, cancel = context.WithDeadline(flightCtx, deadline)
+		}
+		go func() {
+			defer cancel()
+			f.res, f.err = fn(flightCtx, &f.stats)
+
+			g.mu.Lock()
+			delete(g.flights, key)
+			g.mu.Unlock()
+			close(f.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.res, f.stats, shared, f.err
+	case <-ctx.Done():
+		return nil, callStats{}, shared, ctx.Err()
+	}
+}
+
+// coalesceKey identifies identical requests: same URL, same headers and same
+// body. It reports false when the body cannot be compared, e.g. a reader, in
+// which case the request is not coalesced.
+func (api *API) coalesceKey(uri string, headers http.Header, params interface{}) (string, bool) {
+	var body []byte
+	switch p := params.(type) {
+	case nil:
+	case []byte:
+		body = p
+	case io.Reader, ReplayableBody:
+		return "", false
+	default:
+		var err error
+		if body, err = json.Marshal(p); err != nil {
+			return "", false
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(api.BaseURL + uri)
+	b.WriteString("\n")
+	// Header.Write sorts the headers, so equal headers give equal keys
+	_ = headers.Write(&b)
+	b.WriteString("\n")
+	b.Write(body)
+	return b.String(), true
+}
+
+// CoalescedRequests returns the number of calls which shared the in-flight
+// request of an identical call instead of sending their own, since the client
+// was created. It is 0 unless coalescing is enabled with
+// UsingRequestCoalescing.
+func (api *API) CoalescedRequests() int64 {
+	if api.flights == nil {
+		return 0
+	}
+	return api.flights.coalesced.Load()
+}
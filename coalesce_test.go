@@ -0,0 +1,186 @@
+package controld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingDevicesHandler counts the requests it receives and answers them
+// once release is closed.
+func blockingDevicesHandler(hits *atomic.Int64, release <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"devices": [{"PK": "device1"}]}, "success": true}`)
+	}
+}
+
+func TestRequestCoalescing(t *testing.T) {
+	observer := NewPrometheusObserver()
+	setup(UsingRequestCoalescing(true), UsingObserver(observer))
+	defer teardown()
+
+	var hits atomic.Int64
+	release := make(chan struct{})
+	mux.HandleFunc("/devices", blockingDevicesHandler(&hits, release))
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([][]Device, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = client.ListDevices(context.Background())
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		return client.CoalescedRequests() == callers-1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), hits.Load())
+	for i := range callers {
+		require.NoError(t, errs[i])
+		require.Len(t, results[i], 1)
+		assert.Equal(t, "device1", results[i][0].PK)
+	}
+
+	var metrics strings.Builder
+	require.NoError(t, observer.writeMetrics(&metrics))
+	assert.Contains(t, metrics.String(), `controld_requests_coalesced_total{method="GET",route="/devices"} 4`)
+
+	// the flight is over, the next call sends its own request
+	_, err := client.ListDevices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), hits.Load())
+}
+
+func TestRequestCoalescingDistinguishesRequests(t *testing.T) {
+	setup(UsingRequestCoalescing(true))
+	defer teardown()
+
+	var hits atomic.Int64
+	release := make(chan struct{})
+	mux.HandleFunc("/devices", blockingDevicesHandler(&hits, release))
+
+	var wg sync.WaitGroup
+	for _, org := range []string{"org1", "org2", "org3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ListDevices(context.Background(), WithOrganization(org))
+			assert.NoError(t, err)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return hits.Load() == 3 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Zero(t, client.CoalescedRequests())
+}
+
+func TestRequestCoalescingCallerCancellation(t *testing.T) {
+	setup(UsingRequestCoalescing(true))
+	defer teardown()
+
+	var hits atomic.Int64
+	release := make(chan struct{})
+	mux.HandleFunc("/devices", blockingDevicesHandler(&hits, release))
+
+	// the caller which started the flight gives up, the other one still gets
+	// the result
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.ListDevices(ctx)
+		leaderErr <- err
+	}()
+	require.Eventually(t, func() bool { return hits.Load() == 1 }, time.Second, time.Millisecond)
+
+	followerErr := make(chan error, 1)
+	go func() {
+		_, err := client.ListDevices(context.Background())
+		followerErr <- err
+	}()
+	require.Eventually(t, func() bool { return client.CoalescedRequests() == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+	close(release)
+	assert.NoError(t, <-followerErr)
+	assert.Equal(t, int64(1), hits.Load())
+}
+
+func TestRequestCoalescingDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var hits atomic.Int64
+	release := make(chan struct{})
+	mux.HandleFunc("/devices", blockingDevicesHandler(&hits, release))
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ListDevices(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return hits.Load() == 2 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Zero(t, client.CoalescedRequests())
+}
+
+func TestRequestCoalescingDistinguishesBodies(t *testing.T) {
+	setup(UsingRequestCoalescing(true))
+	defer teardown()
+
+	deviceIPs := map[string]string{"dev-a": "10.0.0.1", "dev-b": "10.0.0.2"}
+	var hits atomic.Int64
+	release := make(chan struct{})
+	mux.HandleFunc("/access", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		var params ListKnownIPsParams
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"body": {"ips": [{"ip": %q}]}, "success": true}`, deviceIPs[params.DeviceID])
+	})
+
+	var wg sync.WaitGroup
+	for device, ip := range deviceIPs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ips, err := client.ListKnownIPs(context.Background(), ListKnownIPsParams{DeviceID: device})
+			assert.NoError(t, err)
+			if assert.Len(t, ips, 1) {
+				assert.Equal(t, ip, ips[0].IP.String())
+			}
+		}()
+	}
+
+	require.Eventually(t, func() bool { return hits.Load() == 2 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Zero(t, client.CoalescedRequests())
+}
@@ -5,4 +5,8 @@ const (
 	defaultHostname = "api.controld.com"
 	defaultBasePath = "/"
 	userAgent       = "controld-go"
+
+	// defaultMaxReplayBodySize is the largest streamed request body buffered
+	// in memory so that it can be sent again on retries.
+	defaultMaxReplayBodySize = 10 << 20
 )
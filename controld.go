@@ -1,51 +1,68 @@
 package controld
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/goccy/go-json"
-	"golang.org/x/time/rate"
 	"io"
-	"log"
-	"math"
+	"log/slog"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
-	"strings"
+	"slices"
 	"time"
 )
 
-// API holds the configuration for the current API client. A client should not
-// be modified concurrently.
+// API holds the configuration for the current API client. A client is safe
+// for concurrent use by multiple goroutines. Its exported fields must not be
+// modified once the client is in use; derive a new client with With instead.
 type API struct {
-	APIToken    string
-	BaseURL     string
-	UserAgent   string
-	headers     http.Header
-	httpClient  *http.Client
-	rateLimiter *rate.Limiter
-	retryPolicy RetryPolicy
-	logger      Logger
-	Debug       bool
+	// Deprecated: APIToken only holds the token given to New. The token sent
+	// with requests comes from the TokenSource of the client.
+	APIToken       string
+	BaseURL        string
+	UserAgent      string
+	headers        http.Header
+	tokenSource    TokenSource
+	httpClient     *http.Client
+	rateLimiter    RateLimiter
+	retryPolicy    RetryPolicy
+	logAdapter     *slog.Logger
+	slogger        *slog.Logger
+	observer       Observer
+	cache          Cache
+	cacheTTL       time.Duration
+	organizationID string
+	middleware     []Middleware
+	breaker        *circuitBreaker
+	dryRun         bool
+	planned        *dryRunRecorder
+	defaultTimeout time.Duration
+	flights        *flightGroup
+
+	// customRateLimiter is set when the package user chose the limiter,
+	// which is then kept when the token changes.
+	customRateLimiter bool
+
+	maxReplayBodySize int64
+	Debug             bool
 }
 
 // newClient provides shared logic for New and NewWithUserServiceKey.
 func newClient(opts ...Option) (*API, error) {
-	silentLogger := log.New(io.Discard, "", log.LstdFlags)
-
 	api := &API{
-		BaseURL:     fmt.Sprintf("%s://%s%s", defaultScheme, defaultHostname, defaultBasePath),
-		UserAgent:   userAgent,
-		headers:     make(http.Header),
-		rateLimiter: rate.NewLimiter(rate.Limit(4), 1), // 4rps equates to default api limit (1200 req/5 min)
+		BaseURL:   fmt.Sprintf("%s://%s%s", defaultScheme, defaultHostname, defaultBasePath),
+		UserAgent: userAgent,
+		headers:   make(http.Header),
 		retryPolicy: RetryPolicy{
 			MaxRetries:    3,
 			MinRetryDelay: 1 * time.Second,
 			MaxRetryDelay: 30 * time.Second,
 		},
-		logger: silentLogger,
+		logAdapter:        slog.New(slog.DiscardHandler),
+		planned:           &dryRunRecorder{},
+		defaultTimeout:    defaultTimeout,
+		maxReplayBodySize: defaultMaxReplayBodySize,
 	}
 
 	err := api.parseOptions(opts...)
@@ -53,10 +70,10 @@ func newClient(opts ...Option) (*API, error) {
 		return nil, fmt.Errorf("options parsing failed: %w", err)
 	}
 
-	// Fall back to http.DefaultClient if the package user does not provide
-	// their own.
+	// Fall back to a client with sensible timeouts if the package user does
+	// not provide their own.
 	if api.httpClient == nil {
-		api.httpClient = http.DefaultClient
+		api.httpClient = defaultHTTPClient
 	}
 
 	return api, nil
@@ -73,10 +90,70 @@ func New(token string, opts ...Option) (*API, error) {
 	}
 
 	api.APIToken = token
+	if api.tokenSource == nil {
+		api.tokenSource = StaticTokenSource(token)
+	}
+
+	// Clients using the same token share the same budget unless the package
+	// user provides their own limiter.
+	if api.rateLimiter == nil {
+		api.rateLimiter = sharedRateLimiter(token)
+	}
+
+	return api, nil
+}
+
+// NewWithTokenSource creates a client which asks tokenSource for the API
+// token before every request, so that rotated tokens are picked up while the
+// client is running.
+func NewWithTokenSource(tokenSource TokenSource, opts ...Option) (*API, error) {
+	if tokenSource == nil {
+		return nil, errors.New(errEmptyAPIToken)
+	}
+
+	api, err := newClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	api.tokenSource = tokenSource
+
+	if api.rateLimiter == nil {
+		api.rateLimiter = NewRateLimiter(defaultRateLimit)
+	}
 
 	return api, nil
 }
 
+// With returns a copy of the client with opts applied on top of its
+// configuration, e.g. to use different headers, logger or token. The copy
+// shares the HTTP client, rate limiter, cache and observer of the client
+// unless opts replace them, but does not coalesce its requests with those of
+// the client. A copy with another token uses the rate limiter of that token,
+// unless the limiter was set with UsingRateLimit or UsingRateLimiter. The
+// client itself is left untouched.
+func (api *API) With(opts ...Option) (*API, error) {
+	derived := *api
+	derived.headers = api.headers.Clone()
+	derived.middleware = slices.Clip(api.middleware)
+	if api.flights != nil {
+		derived.flights = newFlightGroup()
+	}
+
+	err := derived.parseOptions(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("options parsing failed: %w", err)
+	}
+
+	return &derived, nil
+}
+
+// RateLimitBudget reports the requests left in the current rate limit window
+// of the client, e.g. for batch jobs to plan their work.
+func (api *API) RateLimitBudget() RateLimitBudget {
+	return api.rateLimiter.Budget()
+}
+
 // makeRequest makes a HTTP request and returns the body as a byte slice,
 // closing it before returning. params will be serialized to JSON.
 //
@@ -85,16 +162,16 @@ func (api *API) makeRequest(method, uri string, params interface{}) ([]byte, err
 	return api.makeRequestWithAuthType(context.Background(), method, uri, params)
 }
 
-func (api *API) makeRequestContext(ctx context.Context, method, uri string, params interface{}) ([]byte, error) {
-	return api.makeRequestWithAuthType(ctx, method, uri, params)
+func (api *API) makeRequestContext(ctx context.Context, method, uri string, params interface{}, opts ...ReqOption) ([]byte, error) {
+	return api.makeRequestWithAuthType(ctx, method, uri, params, opts...)
 }
 
-func (api *API) makeRequestContextWithHeaders(ctx context.Context, method, uri string, params interface{}, headers http.Header) ([]byte, error) {
-	return api.makeRequestWithAuthTypeAndHeaders(ctx, method, uri, params, headers)
+func (api *API) makeRequestContextWithHeaders(ctx context.Context, method, uri string, params interface{}, headers http.Header, opts ...ReqOption) ([]byte, error) {
+	return api.makeRequestWithAuthTypeAndHeaders(ctx, method, uri, params, headers, opts...)
 }
 
-func (api *API) makeRequestWithAuthType(ctx context.Context, method, uri string, params interface{}) ([]byte, error) {
-	return api.makeRequestWithAuthTypeAndHeaders(ctx, method, uri, params, nil)
+func (api *API) makeRequestWithAuthType(ctx context.Context, method, uri string, params interface{}, opts ...ReqOption) ([]byte, error) {
+	return api.makeRequestWithAuthTypeAndHeaders(ctx, method, uri, params, nil, opts...)
 }
 
 // APIResponse holds the structure for a response from the API. It looks alot
@@ -109,8 +186,8 @@ type APIResponse struct {
 	Headers    http.Header
 }
 
-func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, uri string, params interface{}, headers http.Header) ([]byte, error) {
-	res, err := api.makeRequestWithAuthTypeAndHeadersComplete(ctx, method, uri, params, headers)
+func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, uri string, params interface{}, headers http.Header, opts ...ReqOption) ([]byte, error) {
+	res, err := api.makeRequestWithAuthTypeAndHeadersComplete(ctx, method, uri, params, headers, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -120,73 +197,273 @@ func (api *API) makeRequestWithAuthTypeAndHeaders(ctx context.Context, method, u
 // Use this method if an API response can have different Content-Type headers and different body formats.
 //
 //nolint:unused
-func (api *API) makeRequestContextWithHeadersComplete(ctx context.Context, method, uri string, params interface{}, headers http.Header) (*APIResponse, error) {
-	return api.makeRequestWithAuthTypeAndHeadersComplete(ctx, method, uri, params, headers)
+func (api *API) makeRequestContextWithHeadersComplete(ctx context.Context, method, uri string, params interface{}, headers http.Header, opts ...ReqOption) (*APIResponse, error) {
+	return api.makeRequestWithAuthTypeAndHeadersComplete(ctx, method, uri, params, headers, opts...)
+}
+
+func (api *API) makeRequestWithAuthTypeAndHeadersComplete(ctx context.Context, method, uri string, params interface{}, headers http.Header, opts ...ReqOption) (res *APIResponse, err error) {
+	ro := newReqOption(opts...)
+	if ro.meta != nil {
+		defer func() {
+			if err == nil {
+				ro.meta.set(res)
+			}
+		}()
+	}
+	uri = appendQuery(uri, ro.params)
+	headers = ro.mergeHeaders(headers)
+	if api.organizationID != "" && headers.Get(organizationHeader) == "" {
+		headers = headers.Clone()
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set(organizationHeader, api.organizationID)
+	}
+
+	var timeout time.Duration
+	if ro.timeout != nil {
+		timeout = *ro.timeout
+	} else if _, ok := ctx.Deadline(); !ok {
+		timeout = api.defaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if api.isDryRun(method, ro) {
+		return api.planOperation(ctx, method, uri, params)
+	}
+
+	cacheable := api.cache != nil && isCacheable(method, uri)
+	if cacheable {
+		if body, ok := api.cache.Get(api.cacheKey(uri, headers)); ok {
+			return &APIResponse{
+				Body:       body,
+				StatusCode: http.StatusOK,
+				Status:     fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK)),
+				Headers:    make(http.Header),
+			}, nil
+		}
+	}
+
+	start := time.Now()
+	stats := &callStats{}
+
+	retryPolicy := ro.retryPolicy(api.retryPolicy)
+
+	coalesced := false
+	flightKey, coalescable := "", false
+	if api.flights != nil && method == http.MethodGet {
+		flightKey, coalescable = api.coalesceKey(uri, headers, params)
+	}
+	if coalescable {
+		var flightStats callStats
+		res, flightStats, coalesced, err = api.flights.do(ctx, flightKey, func(ctx context.Context, stats *callStats) (*APIResponse, error) {
+			return api.makeRequestWithRetries(ctx, method, uri, params, headers, retryPolicy, stats)
+		})
+		if coalesced {
+			// the call sent no request of its own
+			stats.statusCode = flightStats.statusCode
+			if res != nil {
+				shared := *res
+				res = &shared
+			}
+		} else {
+			*stats = flightStats
+		}
+	} else {
+		res, err = api.makeRequestWithRetries(ctx, method, uri, params, headers, retryPolicy, stats)
+	}
+
+	if api.cache != nil && err == nil && !coalesced {
+		if cacheable {
+			api.cache.Set(api.cacheKey(uri, headers), res.Body, api.cacheTTL)
+		} else if method != http.MethodGet && method != http.MethodHead {
+			api.cache.DeletePrefix(api.cacheInvalidationPrefix(uri))
+		}
+	}
+
+	if api.observer != nil {
+		api.observer.ObserveRequest(RequestObservation{
+			Method:        method,
+			Route:         routeTemplate(uri),
+			StatusCode:    stats.statusCode,
+			ErrorType:     errorTypeOf(err),
+			Duration:      time.Since(start),
+			Attempts:      stats.attempts,
+			RateLimitWait: stats.rateLimitWait,
+			Coalesced:     coalesced,
+		})
+	}
+
+	return res, err
 }
 
-func (api *API) makeRequestWithAuthTypeAndHeadersComplete(ctx context.Context, method, uri string, params interface{}, headers http.Header) (*APIResponse, error) {
+// makeRequestWithRetries sends the request, retrying it according to the
+// retry policy, and maps error responses to typed errors.
+func (api *API) makeRequestWithRetries(ctx context.Context, method, uri string, params interface{}, headers http.Header, retryPolicy RetryPolicy, stats *callStats) (*APIResponse, error) {
 	var err error
 	var resp *http.Response
 	var respErr error
 	var respBody []byte
 
-	for i := 0; i <= api.retryPolicy.MaxRetries; i++ {
-		var reqBody io.Reader
-		if params != nil {
-			if r, ok := params.(io.Reader); ok {
-				reqBody = r
-			} else if paramBytes, ok := params.([]byte); ok {
-				reqBody = bytes.NewReader(paramBytes)
-			} else {
-				var jsonBody []byte
-				jsonBody, err = json.Marshal(params)
-				if err != nil {
-					return nil, fmt.Errorf("error marshalling params to JSON: %w", err)
-				}
-				reqBody = bytes.NewReader(jsonBody)
-			}
+	var retryAfter time.Duration
+	// lastFailure describes why the previous attempt failed
+	var lastFailure error
+
+	body, err := newRequestBody(params, api.maxReplayBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenRefreshed := false
+
+	for i := 0; i <= retryPolicy.MaxRetries; i++ {
+		token, err := api.token(ctx)
+		if err != nil {
+			return nil, err
 		}
 
 		if i > 0 {
-			// expect the backoff introduced here on errored requests to dominate the effect of rate limiting
-			// don't need a random component here as the rate limiter should do something similar
-			// nb time duration could truncate an arbitrary float. Since our inputs are all ints, we should be ok
-			sleepDuration := time.Duration(math.Pow(2, float64(i-1)) * float64(api.retryPolicy.MinRetryDelay))
-
-			if sleepDuration > api.retryPolicy.MaxRetryDelay {
-				sleepDuration = api.retryPolicy.MaxRetryDelay
-			}
-			// useful to do some simple logging here, maybe introduce levels later
-			api.logger.Printf("Sleeping %s before retry attempt number %d for request %s %s", sleepDuration.String(), i, method, uri)
+			// expect the backoff introduced here on errored requests to dominate the effect of rate limiting,
+			// unless the server told us how long to wait through the Retry-After header
+			sleepDuration := retryPolicy.backoff(i, retryAfter)
+
+			api.log(ctx, slog.LevelInfo, "retrying request",
+				slog.String("method", method),
+				slog.String("uri", uri),
+				slog.Int("attempt", i+1),
+				slog.Duration("delay", sleepDuration),
+				slog.String("error", lastFailure.Error()),
+			)
 
 			select {
 			case <-time.After(sleepDuration):
 			case <-ctx.Done():
-				return nil, fmt.Errorf("operation aborted during backoff: %w", ctx.Err())
+				return nil, retryAborted(lastFailure, fmt.Errorf("operation aborted during backoff: %w", ctx.Err()))
+			}
+		}
+
+		// fail fast while the circuit is open, without waiting on the rate
+		// limiter
+		if api.breaker != nil {
+			transition, err := api.breaker.allow()
+			api.logCircuitTransition(ctx, transition)
+			if err != nil {
+				return nil, retryAborted(lastFailure, err)
 			}
 		}
 
+		waitStart := time.Now()
 		err = api.rateLimiter.Wait(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("error caused by request rate limiting: %w", err)
+			if api.breaker != nil {
+				api.breaker.abandon()
+			}
+			return nil, retryAborted(lastFailure, fmt.Errorf("error caused by request rate limiting: %w", err))
+		}
+		wait := time.Since(waitStart)
+		stats.rateLimitWait += wait
+		if wait >= time.Millisecond {
+			api.log(ctx, slog.LevelDebug, "waited for rate limiter",
+				slog.String("method", method),
+				slog.String("uri", uri),
+				slog.Duration("wait", wait),
+			)
+		}
+
+		reqBody, err := body()
+		if err != nil {
+			if api.breaker != nil {
+				api.breaker.abandon()
+			}
+			return nil, retryAborted(lastFailure, err)
 		}
 
-		resp, respErr = api.request(ctx, method, uri, reqBody, headers)
+		api.log(ctx, slog.LevelDebug, "request started",
+			slog.String("method", method),
+			slog.String("uri", uri),
+			slog.Int("attempt", i+1),
+		)
+		start := time.Now()
+		stats.attempts++
+		resp, respErr = api.request(ctx, token, method, uri, params, reqBody, headers)
+		stats.statusCode = 0
+		if resp != nil {
+			stats.statusCode = resp.StatusCode
+			api.rateLimiter.Observe(resp)
+		}
+		if api.breaker != nil {
+			if respErr != nil && errors.Is(respErr, context.Canceled) {
+				api.breaker.abandon()
+			} else {
+				api.logCircuitTransition(ctx, api.breaker.record(isCircuitFailure(resp, respErr)))
+			}
+		}
+		finished := []slog.Attr{
+			slog.String("method", method),
+			slog.String("uri", uri),
+			slog.Int("attempt", i+1),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if respErr != nil {
+			api.log(ctx, slog.LevelWarn, "request failed", append(finished, slog.String("error", respErr.Error()))...)
+		} else {
+			api.log(ctx, slog.LevelDebug, "request finished", append(finished, slog.Int("status", resp.StatusCode))...)
+		}
 
 		// short circuit processing on context timeouts
 		if respErr != nil && errors.Is(respErr, context.DeadlineExceeded) {
 			return nil, respErr
 		}
 
-		// retry if the server is rate limiting us or if it failed
-		// assumes server operations are rolled back on failure
-		if respErr != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
-			if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
-				respErr = errors.New("exceeded available rate limit retries")
+		// the token may have been rotated, try once more with a fresh one
+		if respErr == nil && resp.StatusCode == http.StatusUnauthorized && !tokenRefreshed {
+			if _, ok := api.refreshToken(ctx, token); ok {
+				api.log(ctx, slog.LevelInfo, "retrying request with a refreshed API token",
+					slog.String("method", method),
+					slog.String("uri", uri),
+				)
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				tokenRefreshed = true
+				// the extra attempt is not counted against the retry policy
+				i--
+				continue
 			}
+		}
 
-			if respErr == nil {
-				respErr = fmt.Errorf("received %s response (HTTP %d), please try again later", strings.ToLower(http.StatusText(resp.StatusCode)), resp.StatusCode)
+		// retry if the server is rate limiting us or if it failed, as long as
+		// the retry policy allows it for this method
+		if respErr != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			retry := retryPolicy.shouldRetry(method, resp, respErr)
+			retryAfter = 0
+			lastFailure = respErr
+			if resp != nil {
+				retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+				// read the body so the connection can be reused by the next
+				// attempt, it is kept for the error if this was the last one
+				respBody, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					respErr = fmt.Errorf("could not read response body: %w", err)
+				}
+				// the typed error of the response, wrapped if a retry is
+				// aborted
+				lastFailure = newResponseError(method, api.BaseURL+uri, resp.StatusCode, respBody, stats.attempts)
+			}
+			if retry && retryAfter > 0 && retryPolicy.retryAfterTooLong(ctx, retryAfter) {
+				api.log(ctx, slog.LevelWarn, "not retrying request, Retry-After is too long",
+					slog.String("method", method),
+					slog.String("uri", uri),
+					slog.Duration("retry_after", retryAfter),
+				)
+				retry = false
+			}
+			if !retry {
+				break
 			}
 			continue
 		} else {
@@ -206,43 +483,7 @@ func (api *API) makeRequestWithAuthTypeAndHeadersComplete(ctx context.Context, m
 	}
 
 	if resp.StatusCode >= http.StatusBadRequest {
-		if resp.StatusCode >= http.StatusInternalServerError {
-			return nil, &ServiceError{controldError: &Error{
-				StatusCode: resp.StatusCode,
-				Error: ResponseInfo{
-					Message: errInternalServiceError,
-				},
-			}}
-		}
-
-		errBody := &Response{}
-		err = json.Unmarshal(respBody, &errBody)
-		if err != nil {
-			return nil, fmt.Errorf(errUnmarshalErrorBody+": %w", err)
-		}
-
-		err := &Error{
-			StatusCode: resp.StatusCode,
-			Error:      errBody.Error,
-		}
-
-		switch resp.StatusCode {
-		case http.StatusUnauthorized:
-			err.Type = ErrorTypeAuthorization
-			return nil, &AuthorizationError{controldError: err}
-		case http.StatusForbidden:
-			err.Type = ErrorTypeAuthentication
-			return nil, &AuthenticationError{controldError: err}
-		case http.StatusNotFound:
-			err.Type = ErrorTypeNotFound
-			return nil, &NotFoundError{controldError: err}
-		case http.StatusTooManyRequests:
-			err.Type = ErrorTypeRateLimit
-			return nil, &RatelimitError{controldError: err}
-		default:
-			err.Type = ErrorTypeRequest
-			return nil, &RequestError{controldError: err}
-		}
+		return nil, newResponseError(method, api.BaseURL+uri, resp.StatusCode, respBody, stats.attempts)
 	}
 
 	return &APIResponse{
@@ -253,21 +494,54 @@ func (api *API) makeRequestWithAuthTypeAndHeadersComplete(ctx context.Context, m
 	}, nil
 }
 
+// retryAborted returns err, the reason an attempt could not be made, wrapped
+// in the error of the previous attempt if there was one, so that the typed
+// error of the last response is kept.
+func retryAborted(lastFailure, err error) error {
+	if lastFailure != nil {
+		return fmt.Errorf("%w (retry aborted: %w)", lastFailure, err)
+	}
+	return err
+}
+
 // request makes a HTTP request to the given API endpoint, returning the raw
-// *http.Response, or an error if one occurred. The caller is responsible for
-// closing the response body.
-func (api *API) request(ctx context.Context, method, uri string, reqBody io.Reader, headers http.Header) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, api.BaseURL+uri, reqBody)
+// *http.Response, or an error if one occurred. The request goes through the
+// middleware chain of the client. The caller is responsible for closing the
+// response body.
+func (api *API) request(ctx context.Context, token, method, uri string, params interface{}, reqBody io.Reader, headers http.Header) (*http.Response, error) {
+	req := &OutgoingRequest{
+		Method: method,
+		URI:    uri,
+		Params: params,
+		Body:   reqBody,
+		Header: make(http.Header),
+		token:  token,
+	}
+	copyHeader(req.Header, headers)
+
+	return chainMiddleware(api.send, api.middleware)(ctx, req)
+}
+
+// send is the innermost RequestHandler, which performs the HTTP request.
+func (api *API) send(ctx context.Context, r *OutgoingRequest) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, api.BaseURL+r.URI, r.Body)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request creation failed: %w", err)
 	}
 
 	combinedHeaders := make(http.Header)
 	copyHeader(combinedHeaders, api.headers)
-	copyHeader(combinedHeaders, headers)
+	copyHeader(combinedHeaders, r.Header)
 	req.Header = combinedHeaders
 
-	req.Header.Set("Authorization", "Bearer "+api.APIToken)
+	token := r.token
+	if token == "" {
+		token, err = api.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
 
 	if api.UserAgent != "" {
 		req.Header.Set("User-Agent", api.UserAgent)
@@ -282,8 +556,7 @@ func (api *API) request(ctx context.Context, method, uri string, reqBody io.Read
 		if err != nil {
 			return nil, err
 		}
-
-		log.Printf("\n%s", string(dump))
+		api.dump(ctx, "request dump", dump, token)
 	}
 
 	resp, err := api.httpClient.Do(req)
@@ -296,7 +569,7 @@ func (api *API) request(ctx context.Context, method, uri string, reqBody io.Read
 		if err != nil {
 			return resp, err
 		}
-		log.Printf("\n%s", string(dump))
+		api.dump(ctx, "response dump", dump, token)
 	}
 
 	return resp, nil
@@ -310,23 +583,6 @@ func copyHeader(target, source http.Header) {
 	}
 }
 
-type DateTime struct {
-	time.Time
-}
-
-func (dt DateTime) MarshalJSON() ([]byte, error) {
-	return []byte(dt.Format(time.RFC3339)), nil
-}
-func (dt *DateTime) UnmarshalJSON(data []byte) error {
-	var dateTimeStr = string(data[1 : len(data)-1])
-	parse, err := time.Parse(time.RFC1123Z, dateTimeStr)
-	if err != nil {
-		return err
-	}
-	dt.Time = parse
-	return nil
-}
-
 // ResponseInfo contains a code and message returned by the API as errors or
 // informational messages inside the response.
 type ResponseInfo struct {
@@ -350,9 +606,9 @@ type RawResponse struct {
 
 // Raw makes an HTTP request with user provided params and returns the
 // result as a RawResponse, which contains the untouched JSON result.
-func (api *API) Raw(ctx context.Context, method, endpoint string, data interface{}, headers http.Header) (RawResponse, error) {
+func (api *API) Raw(ctx context.Context, method, endpoint string, data interface{}, headers http.Header, opts ...ReqOption) (RawResponse, error) {
 	var r RawResponse
-	res, err := api.makeRequestContextWithHeaders(ctx, method, endpoint, data, headers)
+	res, err := api.makeRequestContextWithHeaders(ctx, method, endpoint, data, headers, opts...)
 	if err != nil {
 		return r, err
 	}
@@ -363,24 +619,8 @@ func (api *API) Raw(ctx context.Context, method, endpoint string, data interface
 	return r, nil
 }
 
-// RetryPolicy specifies number of retries and min/max retry delays
-// This config is used when the client exponentially backs off after errored requests.
-type RetryPolicy struct {
-	MaxRetries    int
-	MinRetryDelay time.Duration
-	MaxRetryDelay time.Duration
-}
-
 // Logger defines the interface this library needs to use logging
 // This is a subset of the methods implemented in the log package.
 type Logger interface {
 	Printf(format string, v ...interface{})
 }
-
-// ReqOption is a functional option for configuring API requests.
-type ReqOption func(opt *reqOption)
-
-type reqOption struct {
-	//nolint:unused
-	params url.Values
-}
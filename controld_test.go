@@ -1,8 +1,15 @@
 package controld
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -22,13 +29,70 @@ func setup(opts ...Option) {
 	server = httptest.NewServer(mux)
 
 	// disable rate limits and retries in testing - prepended so any provided value overrides this
-	opts = append([]Option{UsingRateLimit(100000), UsingRetryPolicy(0, 0, 0)}, opts...)
+	opts = append([]Option{UsingRateLimit(100000), UsingRetryPolicy(0, 0, 0), BaseURL(server.URL)}, opts...)
 
-	// Cloudflare client configured to use test server
+	// Control D client configured to use test server
 	client, _ = New("api.1377", opts...)
-	client.BaseURL = server.URL
 }
 
 func teardown() {
 	server.Close()
 }
+
+func TestWith(t *testing.T) {
+	setup(Headers(http.Header{"X-Team": []string{"core"}}))
+	defer teardown()
+
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"body": {"profiles": [{"PK": %q, "name": %q}]}, "success": true}`, r.Header.Get("Authorization"), r.Header.Get("X-Team"))
+	})
+
+	derived, err := client.With(APIToken("api.derived"), Headers(http.Header{"X-Team": []string{"ops"}}))
+	require.NoError(t, err)
+
+	profiles, err := derived.ListProfiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer api.derived", profiles[0].PK)
+	assert.Equal(t, "ops", profiles[0].Name)
+
+	profiles, err = client.ListProfiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer api.1377", profiles[0].PK, "the original client should be left untouched")
+	assert.Equal(t, "core", profiles[0].Name)
+
+	assert.Same(t, client.httpClient, derived.httpClient)
+	assert.Same(t, client.rateLimiter, derived.rateLimiter)
+
+	_, err = client.With(APIToken(""))
+	assert.Error(t, err)
+}
+
+func TestConcurrentUse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			api := client
+			if i%2 == 0 {
+				var err error
+				api, err = client.With(UserAgent(fmt.Sprintf("worker/%d", i)))
+				if !assert.NoError(t, err) {
+					return
+				}
+			}
+			_, err := api.ListProfiles(context.Background())
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,43 @@
+package controldtest
+
+import (
+	controld "github.com/baptistecdr/controld-go"
+)
+
+func defaultCategories() []controld.Category {
+	return []controld.Category{
+		{PK: "audio", Name: "Audio", Description: "Music and podcast streaming services"},
+		{PK: "social", Name: "Social", Description: "Social networks"},
+	}
+}
+
+func defaultServices() []controld.Service {
+	return []controld.Service{
+		{PK: "spotify", Name: "Spotify", Category: "audio", UnlockLocation: "JFK"},
+		{PK: "deezer", Name: "Deezer", Category: "audio", UnlockLocation: "CDG"},
+		{PK: "facebook", Name: "Facebook", Category: "social", UnlockLocation: "JFK"},
+		{PK: "instagram", Name: "Instagram", Category: "social", UnlockLocation: "JFK"},
+	}
+}
+
+func defaultNativeFilters() []controld.Filter {
+	return []controld.Filter{
+		{PK: "ads", Name: "Ads & Trackers", Description: "Block advertising and tracking domains"},
+		{PK: "malware", Name: "Malware", Description: "Block malicious domains"},
+		{PK: "social", Name: "Social", Description: "Block social networks"},
+	}
+}
+
+func defaultExternalFilters() []controld.Filter {
+	return []controld.Filter{
+		{PK: "x-oisd", Name: "OISD", Description: "Big and small domain blocklist", Sources: []string{"https://oisd.nl"}},
+	}
+}
+
+func defaultProfileOptions() []controld.ProfilesOption {
+	return []controld.ProfilesOption{
+		{PK: "ai_malware", Title: "AI Malware Filter", Type: controld.Dropdown, DefaultValue: "0.9"},
+		{PK: "block_rfc1918", Title: "DNS Rebind Protection", Type: controld.Toggle, DefaultValue: 0},
+		{PK: "ttl_blck", Title: "Block TTL", Type: controld.Field, DefaultValue: 10},
+	}
+}
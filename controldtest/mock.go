@@ -0,0 +1,451 @@
+package controldtest
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	controld "github.com/baptistecdr/controld-go"
+)
+
+// Call is a call recorded by MockClient.
+type Call struct {
+	// Method is the name of the called method, e.g. "ListDevices".
+	Method string
+
+	// Params holds the arguments of the call besides the context and request
+	// options: the params struct of most methods, nil when there is none.
+	Params any
+
+	// Options are the request options of the call.
+	Options []controld.ReqOption
+}
+
+// MockClient is a controld.Client for unit tests. Each method calls the
+// function field named after it with a Func suffix, e.g. ListDevicesFunc, to
+// produce its result; methods whose function is nil return zero values and a
+// nil error. Every call is recorded. A MockClient is safe for concurrent use
+// once its functions are set.
+type MockClient struct {
+	ListKnownIPsFunc               func(ctx context.Context, params controld.ListKnownIPsParams, opts ...controld.ReqOption) ([]controld.KnownIP, error)
+	LearnNewIPsFunc                func(ctx context.Context, params controld.LearnNewIPsParams, opts ...controld.ReqOption) (controld.UpdateResult, error)
+	DeleteLearnedIPsFunc           func(ctx context.Context, params controld.DeleteLearnedIPsParams, opts ...controld.ReqOption) (controld.DeleteResult, error)
+	ListUserFunc                   func(ctx context.Context, opts ...controld.ReqOption) (controld.User, error)
+	ListLogLevelsFunc              func(ctx context.Context, opts ...controld.ReqOption) ([]controld.LogLevel, error)
+	ListStorageRegionsFunc         func(ctx context.Context, opts ...controld.ReqOption) ([]controld.Endpoint, error)
+	ListDevicesFunc                func(ctx context.Context, opts ...controld.ReqOption) ([]controld.Device, error)
+	CreateDeviceFunc               func(ctx context.Context, params controld.CreateDeviceParams, opts ...controld.ReqOption) (controld.Device, error)
+	ListDeviceTypeFunc             func(ctx context.Context, opts ...controld.ReqOption) (controld.DeviceTypes, error)
+	UpdateDeviceFunc               func(ctx context.Context, params controld.UpdateDeviceParams, opts ...controld.ReqOption) (controld.Device, error)
+	DeleteDeviceFunc               func(ctx context.Context, params controld.DeleteDeviceParams, opts ...controld.ReqOption) (controld.DeleteResult, error)
+	ListIPFunc                     func(ctx context.Context, opts ...controld.ReqOption) (controld.IP, error)
+	ListNetworkFunc                func(ctx context.Context, opts ...controld.ReqOption) ([]controld.Network, error)
+	ListProfilesFunc               func(ctx context.Context, opts ...controld.ReqOption) ([]controld.Profile, error)
+	CreateProfileFunc              func(ctx context.Context, params controld.CreateProfileParams, opts ...controld.ReqOption) ([]controld.Profile, error)
+	UpdateProfileFunc              func(ctx context.Context, params controld.UpdateProfileParams, opts ...controld.ReqOption) ([]controld.Profile, error)
+	DeleteProfileFunc              func(ctx context.Context, params controld.DeleteProfileParams, opts ...controld.ReqOption) (controld.DeleteResult, error)
+	ListProfilesOptionsFunc        func(ctx context.Context, opts ...controld.ReqOption) ([]controld.ProfilesOption, error)
+	UpdateProfilesOptionFunc       func(ctx context.Context, params controld.UpdateProfilesOption, opts ...controld.ReqOption) (controld.UpdateResult, error)
+	ListProfileCustomRulesFunc     func(ctx context.Context, params controld.ListProfileCustomRulesParams, opts ...controld.ReqOption) ([]controld.Rule, error)
+	CreateProfileCustomRuleFunc    func(ctx context.Context, params controld.CreateProfileCustomRuleParams, opts ...controld.ReqOption) ([]controld.CustomRule, error)
+	UpdateProfileCustomRuleFunc    func(ctx context.Context, params controld.UpdateProfileCustomRuleParams, opts ...controld.ReqOption) ([]controld.CustomRule, error)
+	DeleteProfileCustomRuleFunc    func(ctx context.Context, params controld.DeleteProfileCustomRuleParams, opts ...controld.ReqOption) (controld.DeleteResult, error)
+	ListProfileRuleFoldersFunc     func(ctx context.Context, params controld.ListProfileRuleFoldersParams, opts ...controld.ReqOption) ([]controld.Group, error)
+	CreateProfileRuleFolderFunc    func(ctx context.Context, params controld.CreateProfileRuleFolderParams, opts ...controld.ReqOption) ([]controld.Group, error)
+	UpdateProfileRuleFolderFunc    func(ctx context.Context, params controld.UpdateProfileRuleFolderParams, opts ...controld.ReqOption) ([]controld.Group, error)
+	DeleteProfileRuleFolderFunc    func(ctx context.Context, params controld.DeleteProfileRuleFolderParams, opts ...controld.ReqOption) (controld.DeleteResult, error)
+	ListProfileDefaultRuleFunc     func(ctx context.Context, params controld.ListProfileDefaultRuleParams, opts ...controld.ReqOption) (controld.DefaultRule, error)
+	UpdateProfileDefaultRuleFunc   func(ctx context.Context, params controld.UpdateProfileDefaultRuleParams, opts ...controld.ReqOption) (controld.DefaultRule, error)
+	ListServiceCategoriesFunc      func(ctx context.Context, opts ...controld.ReqOption) ([]controld.Category, error)
+	ListServicesFunc               func(ctx context.Context, params controld.ListServicesParams, opts ...controld.ReqOption) ([]controld.Service, error)
+	ListProfileServicesFunc        func(ctx context.Context, params controld.ListProfileServicesParams, opts ...controld.ReqOption) ([]controld.ProfileService, error)
+	UpdateProfileServiceFunc       func(ctx context.Context, params controld.UpdateProfileServiceParams, opts ...controld.ReqOption) ([]controld.Action, error)
+	ListProfileNativeFiltersFunc   func(ctx context.Context, params controld.ListProfileFiltersParams, opts ...controld.ReqOption) ([]controld.Filter, error)
+	ListProfileExternalFiltersFunc func(ctx context.Context, params controld.ListProfileFiltersParams, opts ...controld.ReqOption) ([]controld.Filter, error)
+	UpdateProfileFilterFunc        func(ctx context.Context, params controld.UpdateProfileFilterParams, opts ...controld.ReqOption) (controld.UpdateResult, error)
+	RawFunc                        func(ctx context.Context, method, endpoint string, data interface{}, headers http.Header, opts ...controld.ReqOption) (controld.RawResponse, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ controld.Client = (*MockClient)(nil)
+
+// Calls returns the recorded calls, in order.
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the recorded calls of method, in order.
+func (m *MockClient) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (m *MockClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = nil
+}
+
+func (m *MockClient) record(method string, params any, opts []controld.ReqOption) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{Method: method, Params: params, Options: opts})
+}
+
+// ListKnownIPs implements controld.Client.
+func (m *MockClient) ListKnownIPs(ctx context.Context, params controld.ListKnownIPsParams, opts ...controld.ReqOption) ([]controld.KnownIP, error) {
+	m.record("ListKnownIPs", params, opts)
+	if m.ListKnownIPsFunc == nil {
+		return nil, nil
+	}
+	return m.ListKnownIPsFunc(ctx, params, opts...)
+}
+
+// LearnNewIPs implements controld.Client.
+func (m *MockClient) LearnNewIPs(ctx context.Context, params controld.LearnNewIPsParams, opts ...controld.ReqOption) (controld.UpdateResult, error) {
+	m.record("LearnNewIPs", params, opts)
+	if m.LearnNewIPsFunc == nil {
+		return controld.UpdateResult{}, nil
+	}
+	return m.LearnNewIPsFunc(ctx, params, opts...)
+}
+
+// DeleteLearnedIPs implements controld.Client.
+func (m *MockClient) DeleteLearnedIPs(ctx context.Context, params controld.DeleteLearnedIPsParams, opts ...controld.ReqOption) (controld.DeleteResult, error) {
+	m.record("DeleteLearnedIPs", params, opts)
+	if m.DeleteLearnedIPsFunc == nil {
+		return controld.DeleteResult{}, nil
+	}
+	return m.DeleteLearnedIPsFunc(ctx, params, opts...)
+}
+
+// ListUser implements controld.Client.
+func (m *MockClient) ListUser(ctx context.Context, opts ...controld.ReqOption) (controld.User, error) {
+	m.record("ListUser", nil, opts)
+	if m.ListUserFunc == nil {
+		return controld.User{}, nil
+	}
+	return m.ListUserFunc(ctx, opts...)
+}
+
+// ListLogLevels implements controld.Client.
+func (m *MockClient) ListLogLevels(ctx context.Context, opts ...controld.ReqOption) ([]controld.LogLevel, error) {
+	m.record("ListLogLevels", nil, opts)
+	if m.ListLogLevelsFunc == nil {
+		return nil, nil
+	}
+	return m.ListLogLevelsFunc(ctx, opts...)
+}
+
+// ListStorageRegions implements controld.Client.
+func (m *MockClient) ListStorageRegions(ctx context.Context, opts ...controld.ReqOption) ([]controld.Endpoint, error) {
+	m.record("ListStorageRegions", nil, opts)
+	if m.ListStorageRegionsFunc == nil {
+		return nil, nil
+	}
+	return m.ListStorageRegionsFunc(ctx, opts...)
+}
+
+// ListDevices implements controld.Client.
+func (m *MockClient) ListDevices(ctx context.Context, opts ...controld.ReqOption) ([]controld.Device, error) {
+	m.record("ListDevices", nil, opts)
+	if m.ListDevicesFunc == nil {
+		return nil, nil
+	}
+	return m.ListDevicesFunc(ctx, opts...)
+}
+
+// CreateDevice implements controld.Client.
+func (m *MockClient) CreateDevice(ctx context.Context, params controld.CreateDeviceParams, opts ...controld.ReqOption) (controld.Device, error) {
+	m.record("CreateDevice", params, opts)
+	if m.CreateDeviceFunc == nil {
+		return controld.Device{}, nil
+	}
+	return m.CreateDeviceFunc(ctx, params, opts...)
+}
+
+// ListDeviceType implements controld.Client.
+func (m *MockClient) ListDeviceType(ctx context.Context, opts ...controld.ReqOption) (controld.DeviceTypes, error) {
+	m.record("ListDeviceType", nil, opts)
+	if m.ListDeviceTypeFunc == nil {
+		return controld.DeviceTypes{}, nil
+	}
+	return m.ListDeviceTypeFunc(ctx, opts...)
+}
+
+// UpdateDevice implements controld.Client.
+func (m *MockClient) UpdateDevice(ctx context.Context, params controld.UpdateDeviceParams, opts ...controld.ReqOption) (controld.Device, error) {
+	m.record("UpdateDevice", params, opts)
+	if m.UpdateDeviceFunc == nil {
+		return controld.Device{}, nil
+	}
+	return m.UpdateDeviceFunc(ctx, params, opts...)
+}
+
+// DeleteDevice implements controld.Client.
+func (m *MockClient) DeleteDevice(ctx context.Context, params controld.DeleteDeviceParams, opts ...controld.ReqOption) (controld.DeleteResult, error) {
+	m.record("DeleteDevice", params, opts)
+	if m.DeleteDeviceFunc == nil {
+		return controld.DeleteResult{}, nil
+	}
+	return m.DeleteDeviceFunc(ctx, params, opts...)
+}
+
+// ListIP implements controld.Client.
+func (m *MockClient) ListIP(ctx context.Context, opts ...controld.ReqOption) (controld.IP, error) {
+	m.record("ListIP", nil, opts)
+	if m.ListIPFunc == nil {
+		return controld.IP{}, nil
+	}
+	return m.ListIPFunc(ctx, opts...)
+}
+
+// ListNetwork implements controld.Client.
+func (m *MockClient) ListNetwork(ctx context.Context, opts ...controld.ReqOption) ([]controld.Network, error) {
+	m.record("ListNetwork", nil, opts)
+	if m.ListNetworkFunc == nil {
+		return nil, nil
+	}
+	return m.ListNetworkFunc(ctx, opts...)
+}
+
+// ListProfiles implements controld.Client.
+func (m *MockClient) ListProfiles(ctx context.Context, opts ...controld.ReqOption) ([]controld.Profile, error) {
+	m.record("ListProfiles", nil, opts)
+	if m.ListProfilesFunc == nil {
+		return nil, nil
+	}
+	return m.ListProfilesFunc(ctx, opts...)
+}
+
+// CreateProfile implements controld.Client.
+func (m *MockClient) CreateProfile(ctx context.Context, params controld.CreateProfileParams, opts ...controld.ReqOption) ([]controld.Profile, error) {
+	m.record("CreateProfile", params, opts)
+	if m.CreateProfileFunc == nil {
+		return nil, nil
+	}
+	return m.CreateProfileFunc(ctx, params, opts...)
+}
+
+// UpdateProfile implements controld.Client.
+func (m *MockClient) UpdateProfile(ctx context.Context, params controld.UpdateProfileParams, opts ...controld.ReqOption) ([]controld.Profile, error) {
+	m.record("UpdateProfile", params, opts)
+	if m.UpdateProfileFunc == nil {
+		return nil, nil
+	}
+	return m.UpdateProfileFunc(ctx, params, opts...)
+}
+
+// DeleteProfile implements controld.Client.
+func (m *MockClient) DeleteProfile(ctx context.Context, params controld.DeleteProfileParams, opts ...controld.ReqOption) (controld.DeleteResult, error) {
+	m.record("DeleteProfile", params, opts)
+	if m.DeleteProfileFunc == nil {
+		return controld.DeleteResult{}, nil
+	}
+	return m.DeleteProfileFunc(ctx, params, opts...)
+}
+
+// ListProfilesOptions implements controld.Client.
+func (m *MockClient) ListProfilesOptions(ctx context.Context, opts ...controld.ReqOption) ([]controld.ProfilesOption, error) {
+	m.record("ListProfilesOptions", nil, opts)
+	if m.ListProfilesOptionsFunc == nil {
+		return nil, nil
+	}
+	return m.ListProfilesOptionsFunc(ctx, opts...)
+}
+
+// UpdateProfilesOption implements controld.Client.
+func (m *MockClient) UpdateProfilesOption(ctx context.Context, params controld.UpdateProfilesOption, opts ...controld.ReqOption) (controld.UpdateResult, error) {
+	m.record("UpdateProfilesOption", params, opts)
+	if m.UpdateProfilesOptionFunc == nil {
+		return controld.UpdateResult{}, nil
+	}
+	return m.UpdateProfilesOptionFunc(ctx, params, opts...)
+}
+
+// ListProfileCustomRules implements controld.Client.
+func (m *MockClient) ListProfileCustomRules(ctx context.Context, params controld.ListProfileCustomRulesParams, opts ...controld.ReqOption) ([]controld.Rule, error) {
+	m.record("ListProfileCustomRules", params, opts)
+	if m.ListProfileCustomRulesFunc == nil {
+		return nil, nil
+	}
+	return m.ListProfileCustomRulesFunc(ctx, params, opts...)
+}
+
+// CreateProfileCustomRule implements controld.Client.
+func (m *MockClient) CreateProfileCustomRule(ctx context.Context, params controld.CreateProfileCustomRuleParams, opts ...controld.ReqOption) ([]controld.CustomRule, error) {
+	m.record("CreateProfileCustomRule", params, opts)
+	if m.CreateProfileCustomRuleFunc == nil {
+		return nil, nil
+	}
+	return m.CreateProfileCustomRuleFunc(ctx, params, opts...)
+}
+
+// UpdateProfileCustomRule implements controld.Client.
+func (m *MockClient) UpdateProfileCustomRule(ctx context.Context, params controld.UpdateProfileCustomRuleParams, opts ...controld.ReqOption) ([]controld.CustomRule, error) {
+	m.record("UpdateProfileCustomRule", params, opts)
+	if m.UpdateProfileCustomRuleFunc == nil {
+		return nil, nil
+	}
+	return m.UpdateProfileCustomRuleFunc(ctx, params, opts...)
+}
+
+// DeleteProfileCustomRule implements controld.Client.
+func (m *MockClient) DeleteProfileCustomRule(ctx context.Context, params controld.DeleteProfileCustomRuleParams, opts ...controld.ReqOption) (controld.DeleteResult, error) {
+	m.record("DeleteProfileCustomRule", params, opts)
+	if m.DeleteProfileCustomRuleFunc == nil {
+		return controld.DeleteResult{}, nil
+	}
+	return m.DeleteProfileCustomRuleFunc(ctx, params, opts...)
+}
+
+// ListProfileRuleFolders implements controld.Client.
+func (m *MockClient) ListProfileRuleFolders(ctx context.Context, params controld.ListProfileRuleFoldersParams, opts ...controld.ReqOption) ([]controld.Group, error) {
+	m.record("ListProfileRuleFolders", params, opts)
+	if m.ListProfileRuleFoldersFunc == nil {
+		return nil, nil
+	}
+	return m.ListProfileRuleFoldersFunc(ctx, params, opts...)
+}
+
+// CreateProfileRuleFolder implements controld.Client.
+func (m *MockClient) CreateProfileRuleFolder(ctx context.Context, params controld.CreateProfileRuleFolderParams, opts ...controld.ReqOption) ([]controld.Group, error) {
+	m.record("CreateProfileRuleFolder", params, opts)
+	if m.CreateProfileRuleFolderFunc == nil {
+		return nil, nil
+	}
+	return m.CreateProfileRuleFolderFunc(ctx, params, opts...)
+}
+
+// UpdateProfileRuleFolder implements controld.Client.
+func (m *MockClient) UpdateProfileRuleFolder(ctx context.Context, params controld.UpdateProfileRuleFolderParams, opts ...controld.ReqOption) ([]controld.Group, error) {
+	m.record("UpdateProfileRuleFolder", params, opts)
+	if m.UpdateProfileRuleFolderFunc == nil {
+		return nil, nil
+	}
+	return m.UpdateProfileRuleFolderFunc(ctx, params, opts...)
+}
+
+// DeleteProfileRuleFolder implements controld.Client.
+func (m *MockClient) DeleteProfileRuleFolder(ctx context.Context, params controld.DeleteProfileRuleFolderParams, opts ...controld.ReqOption) (controld.DeleteResult, error) {
+	m.record("DeleteProfileRuleFolder", params, opts)
+	if m.DeleteProfileRuleFolderFunc == nil {
+		return controld.DeleteResult{}, nil
+	}
+	return m.DeleteProfileRuleFolderFunc(ctx, params, opts...)
+}
+
+// ListProfileDefaultRule implements controld.Client.
+func (m *MockClient) ListProfileDefaultRule(ctx context.Context, params controld.ListProfileDefaultRuleParams, opts ...controld.ReqOption) (controld.DefaultRule, error) {
+	m.record("ListProfileDefaultRule", params, opts)
+	if m.ListProfileDefaultRuleFunc == nil {
+		return controld.DefaultRule{}, nil
+	}
+	return m.ListProfileDefaultRuleFunc(ctx, params, opts...)
+}
+
+// UpdateProfileDefaultRule implements controld.Client.
+func (m *MockClient) UpdateProfileDefaultRule(ctx context.Context, params controld.UpdateProfileDefaultRuleParams, opts ...controld.ReqOption) (controld.DefaultRule, error) {
+	m.record("UpdateProfileDefaultRule", params, opts)
+	if m.UpdateProfileDefaultRuleFunc == nil {
+		return controld.DefaultRule{}, nil
+	}
+	return m.UpdateProfileDefaultRuleFunc(ctx, params, opts...)
+}
+
+// ListServiceCategories implements controld.Client.
+func (m *MockClient) ListServiceCategories(ctx context.Context, opts ...controld.ReqOption) ([]controld.Category, error) {
+	m.record("ListServiceCategories", nil, opts)
+	if m.ListServiceCategoriesFunc == nil {
+		return nil, nil
+	}
+	return m.ListServiceCategoriesFunc(ctx, opts...)
+}
+
+// ListServices implements controld.Client.
+func (m *MockClient) ListServices(ctx context.Context, params controld.ListServicesParams, opts ...controld.ReqOption) ([]controld.Service, error) {
+	m.record("ListServices", params, opts)
+	if m.ListServicesFunc == nil {
+		return nil, nil
+	}
+	return m.ListServicesFunc(ctx, params, opts...)
+}
+
+// ListProfileServices implements controld.Client.
+func (m *MockClient) ListProfileServices(ctx context.Context, params controld.ListProfileServicesParams, opts ...controld.ReqOption) ([]controld.ProfileService, error) {
+	m.record("ListProfileServices", params, opts)
+	if m.ListProfileServicesFunc == nil {
+		return nil, nil
+	}
+	return m.ListProfileServicesFunc(ctx, params, opts...)
+}
+
+// UpdateProfileService implements controld.Client.
+func (m *MockClient) UpdateProfileService(ctx context.Context, params controld.UpdateProfileServiceParams, opts ...controld.ReqOption) ([]controld.Action, error) {
+	m.record("UpdateProfileService", params, opts)
+	if m.UpdateProfileServiceFunc == nil {
+		return nil, nil
+	}
+	return m.UpdateProfileServiceFunc(ctx, params, opts...)
+}
+
+// ListProfileNativeFilters implements controld.Client.
+func (m *MockClient) ListProfileNativeFilters(ctx context.Context, params controld.ListProfileFiltersParams, opts ...controld.ReqOption) ([]controld.Filter, error) {
+	m.record("ListProfileNativeFilters", params, opts)
+	if m.ListProfileNativeFiltersFunc == nil {
+		return nil, nil
+	}
+	return m.ListProfileNativeFiltersFunc(ctx, params, opts...)
+}
+
+// ListProfileExternalFilters implements controld.Client.
+func (m *MockClient) ListProfileExternalFilters(ctx context.Context, params controld.ListProfileFiltersParams, opts ...controld.ReqOption) ([]controld.Filter, error) {
+	m.record("ListProfileExternalFilters", params, opts)
+	if m.ListProfileExternalFiltersFunc == nil {
+		return nil, nil
+	}
+	return m.ListProfileExternalFiltersFunc(ctx, params, opts...)
+}
+
+// UpdateProfileFilter implements controld.Client.
+func (m *MockClient) UpdateProfileFilter(ctx context.Context, params controld.UpdateProfileFilterParams, opts ...controld.ReqOption) (controld.UpdateResult, error) {
+	m.record("UpdateProfileFilter", params, opts)
+	if m.UpdateProfileFilterFunc == nil {
+		return controld.UpdateResult{}, nil
+	}
+	return m.UpdateProfileFilterFunc(ctx, params, opts...)
+}
+
+// Raw implements controld.Client. Its call is recorded with a RawCall as
+// Params.
+func (m *MockClient) Raw(ctx context.Context, method, endpoint string, data interface{}, headers http.Header, opts ...controld.ReqOption) (controld.RawResponse, error) {
+	m.record("Raw", RawCall{Method: method, Endpoint: endpoint, Data: data, Headers: headers}, opts)
+	if m.RawFunc == nil {
+		return controld.RawResponse{}, nil
+	}
+	return m.RawFunc(ctx, method, endpoint, data, headers, opts...)
+}
+
+// RawCall holds the arguments of a recorded call to Raw.
+type RawCall struct {
+	Method   string
+	Endpoint string
+	Data     interface{}
+	Headers  http.Header
+}
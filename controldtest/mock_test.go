@@ -0,0 +1,83 @@
+package controldtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	controld "github.com/baptistecdr/controld-go"
+)
+
+// renameDevice is code under test depending on the narrow DevicesAPI.
+func renameDevice(ctx context.Context, api controld.DevicesAPI, id, name string) error {
+	_, err := api.UpdateDevice(ctx, controld.UpdateDeviceParams{DeviceID: id, Name: controld.Set(name)}, controld.WithCorrelationID("rename"))
+	return err
+}
+
+func TestMockClient(t *testing.T) {
+	mock := &MockClient{
+		ListDevicesFunc: func(ctx context.Context, opts ...controld.ReqOption) ([]controld.Device, error) {
+			return []controld.Device{{PK: "device1"}}, nil
+		},
+		UpdateDeviceFunc: func(ctx context.Context, params controld.UpdateDeviceParams, opts ...controld.ReqOption) (controld.Device, error) {
+			return controld.Device{}, controld.ErrNotFound
+		},
+	}
+	var client controld.Client = mock
+	ctx := context.Background()
+
+	devices, err := client.ListDevices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []controld.Device{{PK: "device1"}}, devices)
+
+	err = renameDevice(ctx, client, "device2", "laptop")
+	assert.True(t, errors.Is(err, controld.ErrNotFound))
+
+	// methods without a function return zero values
+	profiles, err := client.ListProfiles(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, profiles)
+
+	_, err = client.Raw(ctx, http.MethodGet, "/users", nil, nil)
+	require.NoError(t, err)
+
+	calls := mock.Calls()
+	require.Len(t, calls, 4)
+	assert.Equal(t, []string{"ListDevices", "UpdateDevice", "ListProfiles", "Raw"},
+		[]string{calls[0].Method, calls[1].Method, calls[2].Method, calls[3].Method})
+	assert.Nil(t, calls[0].Params)
+
+	updates := mock.CallsTo("UpdateDevice")
+	require.Len(t, updates, 1)
+	params := updates[0].Params.(controld.UpdateDeviceParams)
+	assert.Equal(t, "device2", params.DeviceID)
+	assert.Equal(t, controld.Set("laptop"), params.Name)
+	assert.Len(t, updates[0].Options, 1)
+
+	assert.Equal(t, RawCall{Method: http.MethodGet, Endpoint: "/users"}, calls[3].Params)
+
+	mock.Reset()
+	assert.Empty(t, mock.Calls())
+}
+
+func TestMockClientDo(t *testing.T) {
+	mock := &MockClient{
+		RawFunc: func(ctx context.Context, method, endpoint string, data interface{}, headers http.Header, opts ...controld.ReqOption) (controld.RawResponse, error) {
+			return controld.RawResponse{Body: []byte(`{"queries": 42}`)}, nil
+		},
+	}
+
+	report, err := controld.Do[struct {
+		Queries int `json:"queries"`
+	}](context.Background(), mock, http.MethodGet, "/reports/summary", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 42, report.Queries)
+
+	calls := mock.CallsTo("Raw")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "/reports/summary", calls[0].Params.(RawCall).Endpoint)
+}
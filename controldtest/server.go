@@ -0,0 +1,968 @@
+// Package controldtest provides an in-memory fake of the Control D API for
+// tests. The fake keeps real state: a created device is returned when devices
+// are listed, and unknown IDs are answered with HTTP 404.
+//
+//	server := controldtest.NewServer()
+//	defer server.Close()
+//	api, err := server.Client()
+//
+// Any client can use the fake by pointing controld.BaseURL at server.URL.
+package controldtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	controld "github.com/baptistecdr/controld-go"
+)
+
+// Option is a functional option for configuring a Server.
+type Option func(*Server)
+
+// WithToken makes the server answer HTTP 401 to requests which are not
+// authenticated with token.
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithClock sets the function returning the current time, used to timestamp
+// devices, profiles and learned IPs.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Server is a fake Control D API backed by an httptest.Server. It implements
+// the devices, profiles, profile options, rule folders, custom rules,
+// services, filters, default rule and access endpoints. It is safe for
+// concurrent use.
+type Server struct {
+	*httptest.Server
+
+	token string
+	now   func() time.Time
+
+	mu       sync.Mutex
+	nextID   int
+	devices  []*device
+	profiles []*profile
+
+	categories      []controld.Category
+	services        []controld.Service
+	nativeFilters   []controld.Filter
+	externalFilters []controld.Filter
+	options         []controld.ProfilesOption
+}
+
+type device struct {
+	controld.Device
+	ips []controld.KnownIP
+}
+
+type profile struct {
+	controld.Profile
+	options     map[string]optionState
+	folders     []*controld.Group
+	rules       []*controld.Rule
+	services    map[string]controld.Action
+	filters     map[string]controld.IntBool
+	defaultRule *controld.DefaultRule
+}
+
+type optionState struct {
+	Status controld.IntBool `json:"status"`
+	Value  *string          `json:"value,omitempty"`
+}
+
+// NewServer starts a fake Control D API with no devices or profiles and a
+// small catalogue of services, filters and profile options. The caller must
+// call Close when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		now:             time.Now,
+		categories:      defaultCategories(),
+		services:        defaultServices(),
+		nativeFilters:   defaultNativeFilters(),
+		externalFilters: defaultExternalFilters(),
+		options:         defaultProfileOptions(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(s.handler())
+	return s
+}
+
+// Client returns a client of the server, with rate limiting and retries
+// disabled. opts are applied on top.
+func (s *Server) Client(opts ...controld.Option) (*controld.API, error) {
+	token := s.token
+	if token == "" {
+		token = "controldtest"
+	}
+	opts = append([]controld.Option{
+		controld.BaseURL(s.URL),
+		controld.UsingRateLimit(1_000_000),
+		controld.UsingRetryPolicy(0, 0, 0),
+	}, opts...)
+	return controld.New(token, opts...)
+}
+
+// AddService adds service to the catalogue, creating its category if needed.
+func (s *Server) AddService(service controld.Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !slices.ContainsFunc(s.categories, func(c controld.Category) bool { return c.PK == service.Category }) {
+		s.categories = append(s.categories, controld.Category{PK: service.Category, Name: service.Category})
+	}
+	s.services = append(s.services, service)
+}
+
+// AddNativeFilter adds filter to the catalogue of native filters.
+func (s *Server) AddNativeFilter(filter controld.Filter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nativeFilters = append(s.nativeFilters, filter)
+}
+
+// AddExternalFilter adds filter to the catalogue of external filters.
+func (s *Server) AddExternalFilter(filter controld.Filter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.externalFilters = append(s.externalFilters, filter)
+}
+
+// AddProfileOption adds option to the catalogue of profile options.
+func (s *Server) AddProfileOption(option controld.ProfilesOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.options = append(s.options, option)
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /devices", s.listDevices)
+	mux.HandleFunc("POST /devices", s.createDevice)
+	mux.HandleFunc("PUT /devices/{device}", s.updateDevice)
+	mux.HandleFunc("DELETE /devices/{device}", s.deleteDevice)
+
+	mux.HandleFunc("GET /access", s.listKnownIPs)
+	mux.HandleFunc("POST /access", s.learnIPs)
+	mux.HandleFunc("DELETE /access", s.deleteLearnedIPs)
+
+	mux.HandleFunc("GET /profiles", s.listProfiles)
+	mux.HandleFunc("POST /profiles", s.createProfile)
+	mux.HandleFunc("PUT /profiles/{profile}", s.updateProfile)
+	mux.HandleFunc("DELETE /profiles/{profile}", s.deleteProfile)
+
+	mux.HandleFunc("GET /profiles/options", s.listProfileOptions)
+	mux.HandleFunc("PUT /profiles/{profile}/options/{option}", s.updateProfileOption)
+
+	mux.HandleFunc("GET /profiles/{profile}/groups", s.listRuleFolders)
+	mux.HandleFunc("POST /profiles/{profile}/groups", s.createRuleFolder)
+	mux.HandleFunc("PUT /profiles/{profile}/groups/{folder}", s.updateRuleFolder)
+	mux.HandleFunc("DELETE /profiles/{profile}/groups/{folder}", s.deleteRuleFolder)
+
+	mux.HandleFunc("GET /profiles/{profile}/rules/{folder}", s.listCustomRules)
+	mux.HandleFunc("POST /profiles/{profile}/rules", s.createCustomRules)
+	mux.HandleFunc("PUT /profiles/{profile}/rules", s.updateCustomRules)
+	mux.HandleFunc("DELETE /profiles/{profile}/rules/{hostname}", s.deleteCustomRule)
+
+	mux.HandleFunc("GET /services/categories", s.listServiceCategories)
+	mux.HandleFunc("GET /services/categories/{category}", s.listServices)
+	mux.HandleFunc("GET /profiles/{profile}/services", s.listProfileServices)
+	mux.HandleFunc("PUT /profiles/{profile}/services/{service}", s.updateProfileService)
+
+	mux.HandleFunc("GET /profiles/{profile}/filters", s.listNativeFilters)
+	mux.HandleFunc("GET /profiles/{profile}/filters/external", s.listExternalFilters)
+	mux.HandleFunc("PUT /profiles/{profile}/filters/filter/{filter}", s.updateProfileFilter)
+
+	mux.HandleFunc("GET /profiles/{profile}/default", s.getDefaultRule)
+	mux.HandleFunc("PUT /profiles/{profile}/default", s.updateDefaultRule)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "Route not found")
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+			writeError(w, http.StatusUnauthorized, "Invalid API token")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// id returns a new unique ID. s.mu must be held.
+func (s *Server) id() int {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) device(w http.ResponseWriter, id string) *device {
+	for _, d := range s.devices {
+		if d.PK == id {
+			return d
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Device %s not found", id))
+	return nil
+}
+
+func (s *Server) findProfile(id string) *profile {
+	for _, p := range s.profiles {
+		if p.PK == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) profile(w http.ResponseWriter, r *http.Request) *profile {
+	id := r.PathValue("profile")
+	if p := s.findProfile(id); p != nil {
+		return p
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Profile %s not found", id))
+	return nil
+}
+
+// Devices
+
+func (s *Server) listDevices(w http.ResponseWriter, r *http.Request) {
+	devices := make([]controld.Device, 0, len(s.devices))
+	for _, d := range s.devices {
+		devices = append(devices, s.deviceView(d))
+	}
+	writeBody(w, map[string]any{"devices": devices})
+}
+
+// deviceView returns d with its profile as currently stored.
+func (s *Server) deviceView(d *device) controld.Device {
+	view := d.Device
+	if p := s.findProfile(d.Profile.PK); p != nil {
+		view.Profile = p.Profile
+	}
+	return view
+}
+
+func (s *Server) createDevice(w http.ResponseWriter, r *http.Request) {
+	var params controld.CreateDeviceParams
+	if !decode(w, r, &params) {
+		return
+	}
+	var fields []controld.FieldError
+	if params.Name == "" {
+		fields = append(fields, controld.FieldError{Field: "name", Message: "Name is required"})
+	}
+	p := s.findProfile(params.ProfileID)
+	if p == nil {
+		fields = append(fields, controld.FieldError{Field: "profile_id", Message: "Profile not found"})
+	}
+	if len(fields) > 0 {
+		writeValidationError(w, fields)
+		return
+	}
+
+	pk := "device" + strconv.Itoa(s.id())
+	icon := params.Icon
+	d := &device{Device: controld.Device{
+		PK:       pk,
+		Ts:       controld.UnixTime{Time: s.now().UTC().Truncate(time.Second)},
+		Name:     params.Name,
+		DeviceID: pk,
+		Status:   controld.Pending,
+		Stats:    params.Stats,
+		Resolvers: controld.Resolvers{
+			Uid: pk,
+			DoH: "https://dns.controld.com/" + pk,
+			DoT: pk + ".dns.controld.com",
+		},
+		Profile: p.Profile,
+		Icon:    &icon,
+	}}
+	if params.Desc != nil {
+		d.Desc = *params.Desc
+	}
+	if params.LearnIP != nil {
+		d.LearnIP = *params.LearnIP
+	}
+	d.Restricted = params.Restricted
+	s.devices = append(s.devices, d)
+
+	writeBody(w, s.deviceView(d))
+}
+
+func (s *Server) updateDevice(w http.ResponseWriter, r *http.Request) {
+	d := s.device(w, r.PathValue("device"))
+	if d == nil {
+		return
+	}
+	var params controld.UpdateDeviceParams
+	if !decode(w, r, &params) {
+		return
+	}
+	// the name, profile and status of a device cannot be cleared
+	var cleared []controld.FieldError
+	if params.Name.IsNull() {
+		cleared = append(cleared, controld.FieldError{Field: "name", Message: "Name is required"})
+	}
+	if params.ProfileID.IsNull() {
+		cleared = append(cleared, controld.FieldError{Field: "profile_id", Message: "Profile is required"})
+	}
+	if params.Status.IsNull() {
+		cleared = append(cleared, controld.FieldError{Field: "status", Message: "Status is required"})
+	}
+	if len(cleared) > 0 {
+		writeValidationError(w, cleared)
+		return
+	}
+	if profileID, ok := params.ProfileID.Get(); ok {
+		p := s.findProfile(profileID)
+		if p == nil {
+			writeValidationError(w, []controld.FieldError{{Field: "profile_id", Message: "Profile not found"}})
+			return
+		}
+		d.Profile = p.Profile
+	}
+	if name, ok := params.Name.Get(); ok {
+		d.Name = name
+	}
+	if params.Desc.IsSet() {
+		d.Desc, _ = params.Desc.Get()
+	}
+	if params.Stats.IsSet() {
+		d.Stats = params.Stats.Ptr()
+	}
+	if params.LearnIP.IsSet() {
+		d.LearnIP, _ = params.LearnIP.Get()
+	}
+	if params.Restricted.IsSet() {
+		d.Restricted = params.Restricted.Ptr()
+	}
+	if status, ok := params.Status.Get(); ok {
+		d.Status = status
+	}
+
+	writeBodyMessage(w, s.deviceView(d), "Device updated")
+}
+
+func (s *Server) deleteDevice(w http.ResponseWriter, r *http.Request) {
+	d := s.device(w, r.PathValue("device"))
+	if d == nil {
+		return
+	}
+	s.devices = slices.DeleteFunc(s.devices, func(other *device) bool { return other == d })
+	writeBodyMessage(w, []any{}, "Device deleted")
+}
+
+// Access
+
+// accessParams reads the device and IPs of an access request, given in the
+// JSON body or, for the device, in the query.
+func (s *Server) accessParams(w http.ResponseWriter, r *http.Request) (*device, []net.IP, bool) {
+	var params controld.LearnNewIPsParams
+	if r.ContentLength != 0 && !decode(w, r, &params) {
+		return nil, nil, false
+	}
+	if params.DeviceID == "" {
+		params.DeviceID = r.URL.Query().Get("device_id")
+	}
+	d := s.device(w, params.DeviceID)
+	if d == nil {
+		return nil, nil, false
+	}
+	return d, params.IPs, true
+}
+
+func (s *Server) listKnownIPs(w http.ResponseWriter, r *http.Request) {
+	d, _, ok := s.accessParams(w, r)
+	if !ok {
+		return
+	}
+	ips := d.ips
+	if ips == nil {
+		ips = []controld.KnownIP{}
+	}
+	writeBody(w, map[string]any{"ips": ips})
+}
+
+func (s *Server) learnIPs(w http.ResponseWriter, r *http.Request) {
+	d, ips, ok := s.accessParams(w, r)
+	if !ok {
+		return
+	}
+	for _, ip := range ips {
+		if !slices.ContainsFunc(d.ips, func(known controld.KnownIP) bool { return known.IP.Equal(ip) }) {
+			d.ips = append(d.ips, controld.KnownIP{IP: ip, Ts: controld.UnixTime{Time: s.now().UTC().Truncate(time.Second)}})
+		}
+	}
+	writeBodyMessage(w, []any{}, fmt.Sprintf("%d IPs learned", len(ips)))
+}
+
+func (s *Server) deleteLearnedIPs(w http.ResponseWriter, r *http.Request) {
+	d, ips, ok := s.accessParams(w, r)
+	if !ok {
+		return
+	}
+	d.ips = slices.DeleteFunc(d.ips, func(known controld.KnownIP) bool {
+		return slices.ContainsFunc(ips, known.IP.Equal)
+	})
+	writeBodyMessage(w, []any{}, fmt.Sprintf("%d IPs deleted", len(ips)))
+}
+
+// Profiles
+
+func (s *Server) listProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles := make([]controld.Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		profiles = append(profiles, p.Profile)
+	}
+	writeBody(w, map[string]any{"profiles": profiles})
+}
+
+func (s *Server) createProfile(w http.ResponseWriter, r *http.Request) {
+	var params controld.CreateProfileParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Name == "" {
+		writeValidationError(w, []controld.FieldError{{Field: "name", Message: "Name is required"}})
+		return
+	}
+
+	p := &profile{
+		options:  make(map[string]optionState),
+		services: make(map[string]controld.Action),
+		filters:  make(map[string]controld.IntBool),
+	}
+	if params.CloneProfileID != nil && *params.CloneProfileID != "" {
+		source := s.findProfile(*params.CloneProfileID)
+		if source == nil {
+			writeValidationError(w, []controld.FieldError{{Field: "clone_profile_id", Message: "Profile not found"}})
+			return
+		}
+		p = source.clone()
+	}
+	p.PK = "profile" + strconv.Itoa(s.id())
+	p.Name = params.Name
+	p.Updated = controld.UnixTime{Time: s.now().UTC().Truncate(time.Second)}
+	s.profiles = append(s.profiles, p)
+
+	writeBody(w, map[string]any{"profiles": []controld.Profile{p.Profile}})
+}
+
+// clone returns a deep copy of the rules and settings of p.
+func (p *profile) clone() *profile {
+	c := &profile{
+		options:  make(map[string]optionState, len(p.options)),
+		services: make(map[string]controld.Action, len(p.services)),
+		filters:  make(map[string]controld.IntBool, len(p.filters)),
+	}
+	for k, v := range p.options {
+		c.options[k] = v
+	}
+	for k, v := range p.services {
+		c.services[k] = v
+	}
+	for k, v := range p.filters {
+		c.filters[k] = v
+	}
+	for _, folder := range p.folders {
+		f := *folder
+		c.folders = append(c.folders, &f)
+	}
+	for _, rule := range p.rules {
+		r := *rule
+		c.rules = append(c.rules, &r)
+	}
+	if p.defaultRule != nil {
+		rule := *p.defaultRule
+		c.defaultRule = &rule
+	}
+	return c
+}
+
+func (s *Server) updateProfile(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	var params controld.UpdateProfileParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if name, ok := params.Name.Get(); ok {
+		p.Name = name
+	}
+	p.Updated = controld.UnixTime{Time: s.now().UTC().Truncate(time.Second)}
+
+	writeBody(w, map[string]any{"profiles": []controld.Profile{p.Profile}})
+}
+
+func (s *Server) deleteProfile(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	s.profiles = slices.DeleteFunc(s.profiles, func(other *profile) bool { return other == p })
+	writeBodyMessage(w, []any{}, "Profile deleted")
+}
+
+// Profile options
+
+func (s *Server) listProfileOptions(w http.ResponseWriter, r *http.Request) {
+	writeBody(w, map[string]any{"options": s.options})
+}
+
+func (s *Server) updateProfileOption(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	name := r.PathValue("option")
+	if !slices.ContainsFunc(s.options, func(o controld.ProfilesOption) bool { return o.PK == name }) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Option %s not found", name))
+		return
+	}
+	var params controld.UpdateProfilesOption
+	if !decode(w, r, &params) {
+		return
+	}
+	state := p.options[name]
+	state.Status = params.Status
+	if params.Value.IsSet() {
+		state.Value = params.Value.Ptr()
+	}
+	p.options[name] = state
+
+	writeBody(w, map[string]any{"options": map[string]optionState{name: p.options[name]}})
+}
+
+// Rule folders
+
+func (p *profile) folder(w http.ResponseWriter, id string) *controld.Group {
+	for _, folder := range p.folders {
+		if strconv.Itoa(folder.PK) == id {
+			return folder
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Folder %s not found", id))
+	return nil
+}
+
+func (s *Server) listRuleFolders(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	writeBody(w, map[string]any{"groups": p.folderViews(p.folders)})
+}
+
+// folderViews returns folders with their count of rules.
+func (p *profile) folderViews(folders []*controld.Group) []controld.Group {
+	views := make([]controld.Group, 0, len(folders))
+	for _, folder := range folders {
+		view := *folder
+		view.Count = 0
+		for _, rule := range p.rules {
+			if rule.Group == folder.PK {
+				view.Count++
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+func (s *Server) createRuleFolder(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	var params controld.CreateProfileRuleFolderParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Name == "" {
+		writeValidationError(w, []controld.FieldError{{Field: "name", Message: "Name is required"}})
+		return
+	}
+
+	folder := &controld.Group{
+		PK:     s.id(),
+		Group:  params.Name,
+		Action: controld.GroupAction{Status: true, Do: params.Do},
+	}
+	if params.Status != nil {
+		folder.Action.Status = *params.Status
+	}
+	p.folders = append(p.folders, folder)
+
+	writeBody(w, map[string]any{"groups": p.folderViews([]*controld.Group{folder})})
+}
+
+func (s *Server) updateRuleFolder(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	folder := p.folder(w, r.PathValue("folder"))
+	if folder == nil {
+		return
+	}
+	var params controld.UpdateProfileRuleFolderParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Do.IsSet() {
+		folder.Action.Do = params.Do.Ptr()
+	}
+	if status, ok := params.Status.Get(); ok {
+		folder.Action.Status = status
+	}
+
+	writeBody(w, map[string]any{"groups": p.folderViews([]*controld.Group{folder})})
+}
+
+func (s *Server) deleteRuleFolder(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	folder := p.folder(w, r.PathValue("folder"))
+	if folder == nil {
+		return
+	}
+	p.folders = slices.DeleteFunc(p.folders, func(other *controld.Group) bool { return other == folder })
+	p.rules = slices.DeleteFunc(p.rules, func(rule *controld.Rule) bool { return rule.Group == folder.PK })
+	writeBodyMessage(w, []any{}, "Folder deleted")
+}
+
+// Custom rules
+
+func (p *profile) rule(hostname string) *controld.Rule {
+	for _, rule := range p.rules {
+		if rule.PK == hostname {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (s *Server) listCustomRules(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	folderID := r.PathValue("folder")
+	group := 0
+	if folderID != "0" {
+		folder := p.folder(w, folderID)
+		if folder == nil {
+			return
+		}
+		group = folder.PK
+	}
+	rules := []controld.Rule{}
+	for _, rule := range p.rules {
+		if rule.Group == group {
+			rules = append(rules, *rule)
+		}
+	}
+	writeBody(w, map[string]any{"rules": rules})
+}
+
+// ruleAction validates the action of a custom rule request.
+func (p *profile) ruleAction(w http.ResponseWriter, do controld.DoType, status controld.IntBool, via, viaV6 *string, group *int) (controld.Action, bool) {
+	if group != nil && *group != 0 && p.folder(w, strconv.Itoa(*group)) == nil {
+		return controld.Action{}, false
+	}
+	return controld.Action{Do: do, Status: status, Via: via, ViaV6: viaV6, Group: group}, true
+}
+
+func (s *Server) createCustomRules(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	var params controld.CreateProfileCustomRuleParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if len(params.Hostnames) == 0 {
+		writeValidationError(w, []controld.FieldError{{Field: "hostnames", Message: "At least one hostname is required"}})
+		return
+	}
+	for _, hostname := range params.Hostnames {
+		if p.rule(hostname) != nil {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Rule %s already exists", hostname))
+			return
+		}
+	}
+	action, ok := p.ruleAction(w, params.Do, params.Status, params.Via, params.ViaV6, params.Group)
+	if !ok {
+		return
+	}
+
+	created := make([]controld.CustomRule, 0, len(params.Hostnames))
+	for _, hostname := range params.Hostnames {
+		rule := &controld.Rule{PK: hostname, Order: len(p.rules) + 1, Action: action}
+		if params.Group != nil {
+			rule.Group = *params.Group
+		}
+		p.rules = append(p.rules, rule)
+		created = append(created, controld.CustomRule(action))
+	}
+
+	writeBody(w, map[string]any{"rules": created})
+}
+
+func (s *Server) updateCustomRules(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	var params controld.UpdateProfileCustomRuleParams
+	if !decode(w, r, &params) {
+		return
+	}
+	for _, hostname := range params.Hostnames {
+		if p.rule(hostname) == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Rule %s not found", hostname))
+			return
+		}
+	}
+	action, ok := p.ruleAction(w, params.Do, params.Status, params.Via.Ptr(), params.ViaV6.Ptr(), params.Group.Ptr())
+	if !ok {
+		return
+	}
+
+	updated := make([]controld.CustomRule, 0, len(params.Hostnames))
+	for _, hostname := range params.Hostnames {
+		rule := p.rule(hostname)
+		rule.Action = action
+		if group, ok := params.Group.Get(); ok {
+			rule.Group = group
+		}
+		updated = append(updated, controld.CustomRule(action))
+	}
+
+	writeBody(w, map[string]any{"rules": updated})
+}
+
+func (s *Server) deleteCustomRule(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	hostname := r.PathValue("hostname")
+	rule := p.rule(hostname)
+	if rule == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Rule %s not found", hostname))
+		return
+	}
+	p.rules = slices.DeleteFunc(p.rules, func(other *controld.Rule) bool { return other == rule })
+	writeBodyMessage(w, []any{}, "Custom rule(s) deleted")
+}
+
+// Services
+
+func (s *Server) listServiceCategories(w http.ResponseWriter, r *http.Request) {
+	categories := make([]controld.Category, 0, len(s.categories))
+	for _, category := range s.categories {
+		category.Count = 0
+		for _, service := range s.services {
+			if service.Category == category.PK {
+				category.Count++
+			}
+		}
+		categories = append(categories, category)
+	}
+	writeBody(w, map[string]any{"categories": categories})
+}
+
+func (s *Server) listServices(w http.ResponseWriter, r *http.Request) {
+	category := r.PathValue("category")
+	if !slices.ContainsFunc(s.categories, func(c controld.Category) bool { return c.PK == category }) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Category %s not found", category))
+		return
+	}
+	services := []controld.Service{}
+	for _, service := range s.services {
+		if service.Category == category {
+			services = append(services, service)
+		}
+	}
+	writeBody(w, map[string]any{"services": services})
+}
+
+func (s *Server) listProfileServices(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	services := []controld.ProfileService{}
+	for _, service := range s.services {
+		action, ok := p.services[service.PK]
+		if !ok {
+			continue
+		}
+		services = append(services, controld.ProfileService{
+			PK:             service.PK,
+			Name:           service.Name,
+			Category:       service.Category,
+			UnlockLocation: service.UnlockLocation,
+			Warning:        service.Warning,
+			Action:         action,
+		})
+	}
+	writeBody(w, map[string]any{"services": services})
+}
+
+func (s *Server) updateProfileService(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	name := r.PathValue("service")
+	if !slices.ContainsFunc(s.services, func(service controld.Service) bool { return service.PK == name }) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Service %s not found", name))
+		return
+	}
+	var params controld.UpdateProfileServiceParams
+	if !decode(w, r, &params) {
+		return
+	}
+	action := controld.Action{Do: params.Do, Status: params.Status, Via: params.Via.Ptr(), ViaV6: params.ViaV6.Ptr()}
+	p.services[name] = action
+
+	writeBody(w, map[string]any{"services": []controld.Action{action}})
+}
+
+// Filters
+
+func (s *Server) listNativeFilters(w http.ResponseWriter, r *http.Request) {
+	s.listFilters(w, r, s.nativeFilters)
+}
+
+func (s *Server) listExternalFilters(w http.ResponseWriter, r *http.Request) {
+	s.listFilters(w, r, s.externalFilters)
+}
+
+func (s *Server) listFilters(w http.ResponseWriter, r *http.Request, catalogue []controld.Filter) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	filters := make([]controld.Filter, 0, len(catalogue))
+	for _, filter := range catalogue {
+		filter.Status = p.filters[filter.PK]
+		filters = append(filters, filter)
+	}
+	writeBody(w, map[string]any{"filters": filters})
+}
+
+func (s *Server) updateProfileFilter(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	name := r.PathValue("filter")
+	isFilter := func(filter controld.Filter) bool { return filter.PK == name }
+	if !slices.ContainsFunc(s.nativeFilters, isFilter) && !slices.ContainsFunc(s.externalFilters, isFilter) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Filter %s not found", name))
+		return
+	}
+	var params controld.UpdateProfileFilterParams
+	if !decode(w, r, &params) {
+		return
+	}
+	p.filters[name] = params.Status
+
+	writeBody(w, map[string]any{"filters": map[string]any{name: map[string]any{"status": params.Status}}})
+}
+
+// Default rule
+
+func (s *Server) getDefaultRule(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	if p.defaultRule == nil {
+		// like the API, an empty list when the rule was never modified
+		writeBody(w, map[string]any{"default": []any{}})
+		return
+	}
+	writeBody(w, map[string]any{"default": p.defaultRule})
+}
+
+func (s *Server) updateDefaultRule(w http.ResponseWriter, r *http.Request) {
+	p := s.profile(w, r)
+	if p == nil {
+		return
+	}
+	var params controld.UpdateProfileDefaultRuleParams
+	if !decode(w, r, &params) {
+		return
+	}
+	p.defaultRule = &controld.DefaultRule{Do: params.Do, Status: params.Status, Via: params.Via.Ptr()}
+
+	writeBody(w, map[string]any{"default": p.defaultRule})
+}
+
+// Responses
+
+func decode(w http.ResponseWriter, r *http.Request, params any) bool {
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeBody(w http.ResponseWriter, body any) {
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "body": body})
+}
+
+func writeBodyMessage(w http.ResponseWriter, body any, message string) {
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "body": body, "message": message})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"success": false,
+		"error":   map[string]any{"message": message, "code": status},
+	})
+}
+
+func writeValidationError(w http.ResponseWriter, fields []controld.FieldError) {
+	writeJSON(w, http.StatusBadRequest, map[string]any{
+		"success": false,
+		"error":   map[string]any{"message": "Invalid parameters", "code": http.StatusBadRequest, "fields": fields},
+	})
+}
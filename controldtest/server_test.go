@@ -0,0 +1,292 @@
+package controldtest
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	controld "github.com/baptistecdr/controld-go"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func newClient(t *testing.T, opts ...Option) *controld.API {
+	t.Helper()
+	server := NewServer(opts...)
+	t.Cleanup(server.Close)
+	api, err := server.Client()
+	require.NoError(t, err)
+	return api
+}
+
+func TestDevices(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	api := newClient(t, WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	profiles, err := api.CreateProfile(ctx, controld.CreateProfileParams{Name: "Home"})
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+
+	device, err := api.CreateDevice(ctx, controld.CreateDeviceParams{Name: "laptop", ProfileID: profiles[0].PK, Icon: controld.DesktopMac})
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", device.Name)
+	assert.Equal(t, profiles[0].PK, device.Profile.PK)
+	assert.Equal(t, now, device.Ts.Time)
+	assert.Equal(t, "https://dns.controld.com/"+device.PK, device.Resolvers.DoH)
+
+	devices, err := api.ListDevices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []controld.Device{device}, devices)
+
+	device, err = api.UpdateDevice(ctx, controld.UpdateDeviceParams{DeviceID: device.PK, Name: controld.Set("desktop")})
+	require.NoError(t, err)
+	assert.Equal(t, "desktop", device.Name)
+
+	_, err = api.UpdateDevice(ctx, controld.UpdateDeviceParams{DeviceID: "unknown", Name: controld.Set("desktop")})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+
+	deleted, err := api.DeleteDevice(ctx, controld.DeleteDeviceParams{DeviceID: device.PK})
+	require.NoError(t, err)
+	assert.Equal(t, "Device deleted", deleted.Message)
+	devices, err = api.ListDevices(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+
+	_, err = api.DeleteDevice(ctx, controld.DeleteDeviceParams{DeviceID: device.PK})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+}
+
+func TestCreateDeviceValidation(t *testing.T) {
+	api := newClient(t)
+
+	_, err := api.CreateDevice(context.Background(), controld.CreateDeviceParams{ProfileID: "unknown"})
+	var validationErr *controld.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []controld.FieldError{
+		{Field: "name", Message: "Name is required"},
+		{Field: "profile_id", Message: "Profile not found"},
+	}, validationErr.Fields())
+}
+
+func TestUpdateDeviceClearsFields(t *testing.T) {
+	api := newClient(t)
+	ctx := context.Background()
+
+	profiles, err := api.CreateProfile(ctx, controld.CreateProfileParams{Name: "Home"})
+	require.NoError(t, err)
+	device, err := api.CreateDevice(ctx, controld.CreateDeviceParams{Name: "laptop", ProfileID: profiles[0].PK})
+	require.NoError(t, err)
+
+	device, err = api.UpdateDevice(ctx, controld.UpdateDeviceParams{DeviceID: device.PK, Desc: controld.Set("work"), Stats: controld.Set(controld.Full)})
+	require.NoError(t, err)
+	assert.Equal(t, "work", device.Desc)
+
+	// unset fields are left alone, null ones are cleared
+	device, err = api.UpdateDevice(ctx, controld.UpdateDeviceParams{DeviceID: device.PK, Desc: controld.Null[string]()})
+	require.NoError(t, err)
+	assert.Empty(t, device.Desc)
+	assert.Equal(t, "laptop", device.Name)
+	require.NotNil(t, device.Stats)
+	assert.Equal(t, controld.Full, *device.Stats)
+
+	devices, err := api.ListDevices(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, devices[0].Desc)
+
+	_, err = api.UpdateDevice(ctx, controld.UpdateDeviceParams{DeviceID: device.PK, Name: controld.Null[string]()})
+	var validationErr *controld.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []controld.FieldError{{Field: "name", Message: "Name is required"}}, validationErr.Fields())
+}
+
+func TestAccess(t *testing.T) {
+	api := newClient(t)
+	ctx := context.Background()
+
+	profiles, err := api.CreateProfile(ctx, controld.CreateProfileParams{Name: "Home"})
+	require.NoError(t, err)
+	device, err := api.CreateDevice(ctx, controld.CreateDeviceParams{Name: "router", ProfileID: profiles[0].PK})
+	require.NoError(t, err)
+
+	ips := []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}
+	learned, err := api.LearnNewIPs(ctx, controld.LearnNewIPsParams{DeviceID: device.PK, IPs: ips})
+	require.NoError(t, err)
+	assert.Equal(t, "2 IPs learned", learned.Message)
+	deleted, err := api.DeleteLearnedIPs(ctx, controld.DeleteLearnedIPsParams{DeviceID: device.PK, IPs: ips[:1]})
+	require.NoError(t, err)
+	assert.Equal(t, "1 IPs deleted", deleted.Message)
+
+	known, err := api.ListKnownIPs(ctx, controld.ListKnownIPsParams{DeviceID: device.PK})
+	require.NoError(t, err)
+	require.Len(t, known, 1)
+	assert.True(t, ips[1].Equal(known[0].IP))
+
+	_, err = api.ListKnownIPs(ctx, controld.ListKnownIPsParams{DeviceID: "unknown"})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+}
+
+func TestProfiles(t *testing.T) {
+	api := newClient(t)
+	ctx := context.Background()
+
+	created, err := api.CreateProfile(ctx, controld.CreateProfileParams{Name: "Kids"})
+	require.NoError(t, err)
+	pk := created[0].PK
+
+	updated, err := api.UpdateProfile(ctx, controld.UpdateProfileParams{ProfileID: pk, Name: controld.Set("Teens")})
+	require.NoError(t, err)
+	assert.Equal(t, "Teens", updated[0].Name)
+
+	profiles, err := api.ListProfiles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, updated, profiles)
+
+	options, err := api.ListProfilesOptions(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, options)
+	_, err = api.UpdateProfilesOption(ctx, controld.UpdateProfilesOption{ProfileID: pk, Name: options[0].PK, Status: true, Value: controld.Set("0.5")})
+	require.NoError(t, err)
+	_, err = api.UpdateProfilesOption(ctx, controld.UpdateProfilesOption{ProfileID: pk, Name: "unknown", Status: true})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+
+	_, err = api.DeleteProfile(ctx, controld.DeleteProfileParams{ProfileID: pk})
+	require.NoError(t, err)
+	_, err = api.UpdateProfile(ctx, controld.UpdateProfileParams{ProfileID: pk, Name: controld.Set("Teens")})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+}
+
+func TestRuleFoldersAndCustomRules(t *testing.T) {
+	api := newClient(t)
+	ctx := context.Background()
+
+	profiles, err := api.CreateProfile(ctx, controld.CreateProfileParams{Name: "Home"})
+	require.NoError(t, err)
+	pk := profiles[0].PK
+
+	folders, err := api.CreateProfileRuleFolder(ctx, controld.CreateProfileRuleFolderParams{ProfileID: pk, Name: "Games", Do: ptr(controld.DoType(controld.Block))})
+	require.NoError(t, err)
+	require.Len(t, folders, 1)
+	folder := folders[0]
+
+	_, err = api.CreateProfileCustomRule(ctx, controld.CreateProfileCustomRuleParams{
+		ProfileID: pk,
+		Do:        controld.Block,
+		Status:    true,
+		Group:     &folder.PK,
+		Hostnames: []string{"example.com", "example.org"},
+	})
+	require.NoError(t, err)
+
+	_, err = api.CreateProfileCustomRule(ctx, controld.CreateProfileCustomRuleParams{ProfileID: pk, Status: true, Hostnames: []string{"example.com"}})
+	assert.ErrorIs(t, err, controld.ErrConflict)
+
+	_, err = api.UpdateProfileCustomRule(ctx, controld.UpdateProfileCustomRuleParams{ProfileID: pk, Do: controld.Bypass, Status: true, Group: controld.Set(folder.PK), Hostnames: []string{"example.org"}})
+	require.NoError(t, err)
+
+	rules, err := api.ListProfileCustomRules(ctx, controld.ListProfileCustomRulesParams{ProfileID: pk, FolderID: folderID(folder)})
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "example.com", rules[0].PK)
+	assert.Equal(t, controld.DoType(controld.Bypass), rules[1].Action.Do)
+
+	listed, err := api.ListProfileRuleFolders(ctx, controld.ListProfileRuleFoldersParams{ProfileID: pk})
+	require.NoError(t, err)
+	assert.Equal(t, 2, listed[0].Count)
+
+	_, err = api.DeleteProfileCustomRule(ctx, controld.DeleteProfileCustomRuleParams{ProfileID: pk, Hostname: "example.com"})
+	require.NoError(t, err)
+	_, err = api.DeleteProfileCustomRule(ctx, controld.DeleteProfileCustomRuleParams{ProfileID: pk, Hostname: "example.com"})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+
+	updated, err := api.UpdateProfileRuleFolder(ctx, controld.UpdateProfileRuleFolderParams{ProfileID: pk, FolderID: folderID(folder), Status: controld.Set(controld.IntBool(false))})
+	require.NoError(t, err)
+	assert.Equal(t, controld.IntBool(false), updated[0].Action.Status)
+	assert.Equal(t, 1, updated[0].Count)
+
+	_, err = api.DeleteProfileRuleFolder(ctx, controld.DeleteProfileRuleFolderParams{ProfileID: pk, FolderID: folderID(folder)})
+	require.NoError(t, err)
+	_, err = api.ListProfileCustomRules(ctx, controld.ListProfileCustomRulesParams{ProfileID: pk, FolderID: folderID(folder)})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+}
+
+func TestServicesFiltersAndDefaultRule(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddService(controld.Service{PK: "twitch", Name: "Twitch", Category: "video"})
+	api, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	categories, err := api.ListServiceCategories(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, categories, controld.Category{PK: "video", Name: "video", Count: 1})
+	services, err := api.ListServices(ctx, controld.ListServicesParams{Category: "video"})
+	require.NoError(t, err)
+	assert.Equal(t, "twitch", services[0].PK)
+	_, err = api.ListServices(ctx, controld.ListServicesParams{Category: "unknown"})
+	assert.ErrorIs(t, err, controld.ErrNotFound)
+
+	profiles, err := api.CreateProfile(ctx, controld.CreateProfileParams{Name: "Home"})
+	require.NoError(t, err)
+	pk := profiles[0].PK
+
+	_, err = api.UpdateProfileService(ctx, controld.UpdateProfileServiceParams{ProfileID: pk, Service: "twitch", Do: controld.Block, Status: true})
+	require.NoError(t, err)
+	profileServices, err := api.ListProfileServices(ctx, controld.ListProfileServicesParams{ProfileID: pk})
+	require.NoError(t, err)
+	require.Len(t, profileServices, 1)
+	assert.Equal(t, controld.IntBool(true), profileServices[0].Action.Status)
+
+	_, err = api.UpdateProfileFilter(ctx, controld.UpdateProfileFilterParams{ProfileID: pk, Filter: "ads", Status: true})
+	require.NoError(t, err)
+	filters, err := api.ListProfileNativeFilters(ctx, controld.ListProfileFiltersParams{ProfileID: pk})
+	require.NoError(t, err)
+	assert.Equal(t, controld.IntBool(true), filters[0].Status)
+	assert.Equal(t, controld.IntBool(false), filters[1].Status)
+	external, err := api.ListProfileExternalFilters(ctx, controld.ListProfileFiltersParams{ProfileID: pk})
+	require.NoError(t, err)
+	assert.NotEmpty(t, external)
+
+	rule, err := api.ListProfileDefaultRule(ctx, controld.ListProfileDefaultRuleParams{ProfileID: pk})
+	require.NoError(t, err)
+	assert.Equal(t, controld.DefaultRule{Do: controld.Bypass, Status: true}, rule)
+	_, err = api.UpdateProfileDefaultRule(ctx, controld.UpdateProfileDefaultRuleParams{ProfileID: pk, Do: controld.Block, Status: true})
+	require.NoError(t, err)
+	rule, err = api.ListProfileDefaultRule(ctx, controld.ListProfileDefaultRuleParams{ProfileID: pk})
+	require.NoError(t, err)
+	assert.Equal(t, controld.DoType(controld.Block), rule.Do)
+
+	// clones copy the rules of their source
+	clones, err := api.CreateProfile(ctx, controld.CreateProfileParams{Name: "Copy", CloneProfileID: &pk})
+	require.NoError(t, err)
+	profileServices, err = api.ListProfileServices(ctx, controld.ListProfileServicesParams{ProfileID: clones[0].PK})
+	require.NoError(t, err)
+	assert.Len(t, profileServices, 1)
+}
+
+func TestWithToken(t *testing.T) {
+	server := NewServer(WithToken("api.1377"))
+	defer server.Close()
+
+	api, err := server.Client()
+	require.NoError(t, err)
+	_, err = api.ListProfiles(context.Background())
+	require.NoError(t, err)
+
+	api, err = server.Client(controld.APIToken("api.wrong"))
+	require.NoError(t, err)
+	_, err = api.ListProfiles(context.Background())
+	assert.ErrorIs(t, err, controld.ErrUnauthorized)
+}
+
+func folderID(folder controld.Group) string {
+	return strconv.Itoa(folder.PK)
+}
@@ -6,86 +6,6 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"strconv"
-	"time"
-)
-
-type UnixTime struct {
-	time.Time
-}
-
-func (s UnixTime) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s.Unix())
-}
-
-func (s *UnixTime) UnmarshalJSON(data []byte) error {
-	seconds, err := strconv.ParseInt(string(data), 10, 64)
-	if err != nil {
-		return err
-	}
-
-	s.Time = time.Unix(seconds, 0).UTC()
-	return nil
-}
-
-type IntBool bool
-
-func (s IntBool) MarshalJSON() ([]byte, error) {
-	if s {
-		return json.Marshal(1)
-	} else {
-		return json.Marshal(0)
-	}
-}
-
-func (s *IntBool) UnmarshalJSON(data []byte) error {
-	value, err := strconv.ParseInt(string(data), 10, 64)
-	if err != nil {
-		return err
-	}
-	*s = IntBool(value == 1)
-	return nil
-}
-
-type AnalyticsLevel int
-
-const (
-	Off   AnalyticsLevel = 0
-	Basic AnalyticsLevel = 1
-	Full  AnalyticsLevel = 2
-)
-
-type IconName string
-
-const (
-	DesktopWindows    IconName = "desktop-windows"
-	DesktopMac        IconName = "desktop-mac"
-	DesktopLinux      IconName = "desktop-linux"
-	MobileIOS         IconName = "mobile-ios"
-	MobileAndroid     IconName = "mobile-android"
-	BrowserChrome     IconName = "browser-chrome"
-	BrowserFirefox    IconName = "browser-firefox"
-	BrowserEdge       IconName = "browser-edge"
-	BrowserBrave      IconName = "browser-brave"
-	BrowserOther      IconName = "browser-other"
-	TVApple           IconName = "tv-apple"
-	TVAndroid         IconName = "tv-android"
-	TVFireTV          IconName = "tv-firetv"
-	TVSamsung         IconName = "tv-samsung"
-	TVOther           IconName = "tv"
-	RouterAsus        IconName = "router-asus"
-	RouterDDWRT       IconName = "router-ddwrt"
-	RouterFirewalla   IconName = "router-firewalla"
-	RouterFreshTomato IconName = "router-freshtomato"
-	RouterGLiNET      IconName = "router-glinet"
-	RouterOpenWRT     IconName = "router-openwrt"
-	RouterOPNsense    IconName = "router-opnsense"
-	RouterPfSense     IconName = "router-pfsense"
-	RouterSynology    IconName = "router-synology"
-	RouterUbiquiti    IconName = "router-ubiquiti"
-	RouterWindows     IconName = "router-windows"
-	RouterLinux       IconName = "router-linux"
-	RouterOther       IconName = "router"
 )
 
 type DDNS struct {
@@ -113,15 +33,6 @@ type LegacyIPv4 struct {
 	Status   IntBool `json:"status"`
 }
 
-type DeviceStatus int
-
-const (
-	Pending      = 0
-	Active       = 1
-	SoftDisabled = 2
-	HardDisabled = 3
-)
-
 type Device struct {
 	PK         string          `json:"PK"`
 	Ts         UnixTime        `json:"ts"`
@@ -144,10 +55,7 @@ type ListDevicesBody struct {
 	Devices []Device `json:"devices"`
 }
 
-type ListDevicesResponse struct {
-	Body ListDevicesBody `json:"body"`
-	Response
-}
+type ListDevicesResponse = Envelope[ListDevicesBody]
 
 type CreateDeviceParams struct {
 	Name             string          `json:"name"`
@@ -167,10 +75,7 @@ type CreateDeviceParams struct {
 	RemapClientID    *string         `json:"remap_client_id,omitempty"`
 }
 
-type CreateDeviceResponse struct {
-	Body Device `json:"body"`
-	Response
-}
+type CreateDeviceResponse = Envelope[Device]
 type Icon struct {
 	Name string `json:"name"`
 }
@@ -239,50 +144,39 @@ type ListDeviceTypesBody struct {
 	Types DeviceTypes `json:"types"`
 }
 
-type ListDeviceTypesResponse struct {
-	Body ListDeviceTypesBody `json:"body"`
-	Response
-}
+type ListDeviceTypesResponse = Envelope[ListDeviceTypesBody]
 
 type UpdateDeviceParams struct {
-	DeviceID          string          `json:"id"`
-	Name              *string         `json:"name,omitempty"`
-	ProfileID         *string         `json:"profile_id,omitempty"`
-	ProfileID2        *string         `json:"profile_id2,omitempty"`
-	Stats             *AnalyticsLevel `json:"stats,omitempty"`
-	LegacyIPv4Status  *IntBool        `json:"legacy_ipv4_status,omitempty"`
-	LearnIP           *IntBool        `json:"learn_ip,omitempty"`
-	Restricted        *IntBool        `json:"restricted,omitempty"`
-	BumpTLS           *IntBool        `json:"bump_tls,omitempty"`
-	Desc              *string         `json:"desc,omitempty"`
-	DDNSStatus        *IntBool        `json:"ddns_status,omitempty"`
-	DDNSSubdomain     *string         `json:"ddns_subdomain,omitempty"`
-	DDNSExtHost       *string         `json:"ddns_ext_host,omitempty"`
-	DDNSExtStatus     *IntBool        `json:"ddns_ext_status,omitempty"`
-	Status            *DeviceStatus   `json:"status,omitempty"`
-	CtrldCustomConfig *string         `json:"ctrld_custom_config,omitempty"`
-}
-
-type UpdateDeviceResponse struct {
-	Body    Device `json:"body"`
-	Message string `json:"message"`
-	Response
-}
+	DeviceID          string                   `json:"id"`
+	Name              Optional[string]         `json:"name,omitzero"`
+	ProfileID         Optional[string]         `json:"profile_id,omitzero"`
+	ProfileID2        Optional[string]         `json:"profile_id2,omitzero"`
+	Stats             Optional[AnalyticsLevel] `json:"stats,omitzero"`
+	LegacyIPv4Status  Optional[IntBool]        `json:"legacy_ipv4_status,omitzero"`
+	LearnIP           Optional[IntBool]        `json:"learn_ip,omitzero"`
+	Restricted        Optional[IntBool]        `json:"restricted,omitzero"`
+	BumpTLS           Optional[IntBool]        `json:"bump_tls,omitzero"`
+	Desc              Optional[string]         `json:"desc,omitzero"`
+	DDNSStatus        Optional[IntBool]        `json:"ddns_status,omitzero"`
+	DDNSSubdomain     Optional[string]         `json:"ddns_subdomain,omitzero"`
+	DDNSExtHost       Optional[string]         `json:"ddns_ext_host,omitzero"`
+	DDNSExtStatus     Optional[IntBool]        `json:"ddns_ext_status,omitzero"`
+	Status            Optional[DeviceStatus]   `json:"status,omitzero"`
+	CtrldCustomConfig Optional[string]         `json:"ctrld_custom_config,omitzero"`
+}
+
+type UpdateDeviceResponse = Envelope[Device]
 
 type DeleteDeviceParams struct {
 	DeviceID string `json:"device-id"`
 }
 
-type DeleteDeviceResponse struct {
-	Body    []any  `json:"body"`
-	Message string `json:"message"`
-	Response
-}
+type DeleteDeviceResponse = Envelope[[]any]
 
-func (api *API) ListDevices(ctx context.Context) ([]Device, error) {
+func (api *API) ListDevices(ctx context.Context, opts ...ReqOption) ([]Device, error) {
 	uri := buildURI("/devices", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Device{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -296,10 +190,10 @@ func (api *API) ListDevices(ctx context.Context) ([]Device, error) {
 	return r.Body.Devices, nil
 }
 
-func (api *API) CreateDevice(ctx context.Context, params CreateDeviceParams) (Device, error) {
+func (api *API) CreateDevice(ctx context.Context, params CreateDeviceParams, opts ...ReqOption) (Device, error) {
 	uri := buildURI("/devices", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params, opts...)
 	if err != nil {
 		return Device{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -313,9 +207,9 @@ func (api *API) CreateDevice(ctx context.Context, params CreateDeviceParams) (De
 	return r.Body, nil
 }
 
-func (api *API) ListDeviceType(ctx context.Context) (DeviceTypes, error) {
+func (api *API) ListDeviceType(ctx context.Context, opts ...ReqOption) (DeviceTypes, error) {
 	uri := buildURI("/devices/types", nil)
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return DeviceTypes{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -327,13 +221,16 @@ func (api *API) ListDeviceType(ctx context.Context) (DeviceTypes, error) {
 	return r.Body.Types, nil
 }
 
-func (api *API) UpdateDevice(ctx context.Context, params UpdateDeviceParams) (Device, error) {
+// UpdateDevice returns the updated device, like the other calls returning the
+// resource they change, rather than an UpdateResult. The message of the
+// response, if any, can be read with WithResponseMeta.
+func (api *API) UpdateDevice(ctx context.Context, params UpdateDeviceParams, opts ...ReqOption) (Device, error) {
 	if params.DeviceID == "" {
 		return Device{}, fmt.Errorf("update: no device ID provided")
 	}
 	baseURL := fmt.Sprintf("/devices/%s", params.DeviceID)
 	uri := buildURI(baseURL, nil)
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
 		return Device{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -345,24 +242,22 @@ func (api *API) UpdateDevice(ctx context.Context, params UpdateDeviceParams) (De
 	return r.Body, nil
 }
 
-func (api *API) DeleteDevice(ctx context.Context, params DeleteDeviceParams) ([]any, error) {
+func (api *API) DeleteDevice(ctx context.Context, params DeleteDeviceParams, opts ...ReqOption) (DeleteResult, error) {
 	if params.DeviceID == "" {
-		return []any{}, fmt.Errorf("delete: no device ID provided")
+		return DeleteResult{}, fmt.Errorf("delete: no device ID provided")
 	}
 
 	baseURL := fmt.Sprintf("/devices/%s", params.DeviceID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params, opts...)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return DeleteResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r DeleteDeviceResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[json.RawMessage](res)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return DeleteResult{}, err
 	}
-	return r.Body, nil
+	return DeleteResult{Message: r.Message}, nil
 }
@@ -215,7 +215,7 @@ func TestUpdateDevice(t *testing.T) {
 	mux.HandleFunc(fmt.Sprintf("/devices/%s", deviceID), handler)
 	actual, err := client.UpdateDevice(context.Background(), UpdateDeviceParams{
 		DeviceID: deviceID,
-		Name:     &newDeviceName,
+		Name:     Set(newDeviceName),
 	})
 
 	v6 := []net.IP{net.ParseIP("ef4f:81ab:0618:4663:d938:4cff:8bb2:0d2a"), net.ParseIP("526a:2dc5:a3fe:0732:b240:08ef:ece4:c828")}
@@ -263,8 +263,9 @@ func TestDeleteDevice(t *testing.T) {
 	}
 	deviceID := "deviceID"
 	mux.HandleFunc(fmt.Sprintf("/devices/%s", deviceID), handler)
-	_, err := client.DeleteDevice(context.Background(), DeleteDeviceParams{DeviceID: deviceID})
+	actual, err := client.DeleteDevice(context.Background(), DeleteDeviceParams{DeviceID: deviceID})
 	require.NoError(t, err, "Device should have been deleted")
+	assert.Equal(t, DeleteResult{Message: "Device has been deleted"}, actual)
 
 	_, err = client.DeleteDevice(context.Background(), DeleteDeviceParams{DeviceID: ""})
 	require.Error(t, err, "Device should not have been deleted")
@@ -0,0 +1,32 @@
+package controld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Do makes an HTTP request to an endpoint of the API, e.g. one not covered by
+// this package yet, and decodes the body of the response envelope into T.
+// The request goes through the same rate limiting, retries and error mapping
+// as the other calls of api. Query parameters can be given in path or with
+// WithQuery.
+//
+//	type Report struct {
+//		Queries int `json:"queries"`
+//	}
+//	report, err := controld.Do[Report](ctx, api, http.MethodGet, "/reports/summary", nil, controld.WithQuery("period", "7d"))
+func Do[T any](ctx context.Context, api Client, method, path string, body any, opts ...ReqOption) (T, error) {
+	var result T
+	r, err := api.Raw(ctx, method, path, body, nil, opts...)
+	if err != nil {
+		return result, err
+	}
+	if len(r.Body) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(r.Body, &result); err != nil {
+		return result, fmt.Errorf("%s: %w", errUnmarshalError, err)
+	}
+	return result, nil
+}
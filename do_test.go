@@ -0,0 +1,78 @@
+package controld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testReport struct {
+	Queries int      `json:"queries"`
+	Blocked []string `json:"blocked"`
+}
+
+func TestDo(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/reports/summary", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "7d", r.URL.Query().Get("period"))
+		assert.Equal(t, "device1", r.URL.Query().Get("device"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"queries": 42, "blocked": ["ads.example.com"]}, "success": true}`)
+	})
+
+	report, err := Do[testReport](context.Background(), client, http.MethodGet, "/reports/summary?period=7d", nil, WithQuery("device", "device1"))
+	require.NoError(t, err)
+	assert.Equal(t, testReport{Queries: 42, Blocked: []string{"ads.example.com"}}, report)
+}
+
+func TestDoWithBody(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"period": "30d"}`, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": [{"queries": 1}, {"queries": 2}], "success": true}`)
+	})
+
+	reports, err := Do[[]testReport](context.Background(), client, http.MethodPost, "/reports", map[string]string{"period": "30d"})
+	require.NoError(t, err)
+	assert.Equal(t, []testReport{{Queries: 1}, {Queries: 2}}, reports)
+
+	raw, err := Do[json.RawMessage](context.Background(), client, http.MethodPost, "/reports", map[string]string{"period": "30d"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"queries": 1}, {"queries": 2}]`, string(raw))
+}
+
+func TestDoErrors(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/reports/summary", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"success": false, "error": {"message": "Not found", "code": 404}}`)
+	})
+	mux.HandleFunc("/reports/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"queries": "many"}, "success": true}`)
+	})
+
+	_, err := Do[testReport](context.Background(), client, http.MethodGet, "/reports/summary", nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = Do[testReport](context.Background(), client, http.MethodGet, "/reports/broken", nil)
+	assert.ErrorContains(t, err, errUnmarshalError)
+}
@@ -0,0 +1,127 @@
+package controld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// dryRunResponse is the body returned for mutating calls in dry-run mode.
+var dryRunResponse = []byte(`{"success": true}`)
+
+// PlannedOperation is a mutating API call recorded instead of being sent in
+// dry-run mode.
+type PlannedOperation struct {
+	// Method is the HTTP method of the call.
+	Method string `json:"method"`
+
+	// Path is the path and query of the call, relative to the API BaseURL.
+	Path string `json:"path"`
+
+	// Body is the JSON body of the call, nil when there is none.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// String formats the operation as "METHOD path body" for review, with
+// passwords redacted from the body.
+func (op PlannedOperation) String() string {
+	if len(op.Body) == 0 {
+		return op.Method + " " + op.Path
+	}
+	return op.Method + " " + op.Path + " " + redact(string(op.Body), "")
+}
+
+// dryRunRecorder collects the planned operations of a client and of the
+// clients derived from it.
+type dryRunRecorder struct {
+	mu         sync.Mutex
+	operations []PlannedOperation
+}
+
+// isDryRun reports whether a call is recorded instead of being sent. Only
+// mutating calls are, reads are always sent.
+func (api *API) isDryRun(method string, ro *reqOption) bool {
+	if method == http.MethodGet || method == http.MethodHead {
+		return false
+	}
+	if ro.dryRun != nil {
+		return *ro.dryRun
+	}
+	return api.dryRun
+}
+
+// planOperation records a call made in dry-run mode and returns the response
+// standing in for the one of the API.
+func (api *API) planOperation(ctx context.Context, method, uri string, params interface{}) (*APIResponse, error) {
+	body, err := planBody(params, api.maxReplayBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	op := PlannedOperation{Method: method, Path: uri, Body: body}
+	api.planned.mu.Lock()
+	api.planned.operations = append(api.planned.operations, op)
+	api.planned.mu.Unlock()
+
+	api.log(ctx, slog.LevelInfo, "dry run: request not sent",
+		slog.String("method", method),
+		slog.String("uri", uri),
+	)
+
+	return &APIResponse{
+		Body:       dryRunResponse,
+		StatusCode: http.StatusOK,
+		Status:     fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK)),
+		Headers:    make(http.Header),
+	}, nil
+}
+
+// planBody serializes params as the body of a planned operation. Bodies which
+// are not JSON are kept as a JSON string.
+func planBody(params interface{}, maxSize int64) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	body, err := newRequestBody(params, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	r, err := body()
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read request body: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if json.Valid(b) {
+		return b, nil
+	}
+	return json.Marshal(string(b))
+}
+
+// PlannedOperations returns the mutating calls recorded in dry-run mode by the
+// client and the clients derived from it with With, in order.
+func (api *API) PlannedOperations() []PlannedOperation {
+	api.planned.mu.Lock()
+	defer api.planned.mu.Unlock()
+
+	operations := make([]PlannedOperation, len(api.planned.operations))
+	copy(operations, api.planned.operations)
+	return operations
+}
+
+// ClearPlannedOperations forgets the calls recorded in dry-run mode.
+func (api *API) ClearPlannedOperations() {
+	api.planned.mu.Lock()
+	defer api.planned.mu.Unlock()
+
+	api.planned.operations = nil
+}
@@ -0,0 +1,93 @@
+package controld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	setup(DryRun(true))
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s sent in dry-run mode", r.Method, r.URL)
+	})
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"devices": []}, "success": true}`)
+	})
+
+	ctx := context.Background()
+	_, err := client.ListDevices(ctx)
+	require.NoError(t, err)
+
+	device, err := client.CreateDevice(ctx, CreateDeviceParams{Name: "laptop", ProfileID: "PK", Icon: "desktop-mac"})
+	require.NoError(t, err)
+	assert.Equal(t, Device{}, device)
+
+	password := "s3cret"
+	_, err = client.UpdateProfile(ctx, UpdateProfileParams{ProfileID: "PK", Name: Set("Kids"), Password: Set(password)})
+	require.NoError(t, err)
+
+	_, err = client.DeleteProfileRuleFolder(ctx, DeleteProfileRuleFolderParams{ProfileID: "PK", FolderID: "42"}, WithQuery("force", "1"))
+	require.NoError(t, err)
+
+	operations := client.PlannedOperations()
+	require.Len(t, operations, 3)
+
+	assert.Equal(t, http.MethodPost, operations[0].Method)
+	assert.Equal(t, "/devices", operations[0].Path)
+	assert.JSONEq(t, `{"name": "laptop", "profile_id": "PK", "icon": "desktop-mac"}`, string(operations[0].Body))
+
+	assert.Equal(t, http.MethodPut, operations[1].Method)
+	assert.Equal(t, "/profiles/PK", operations[1].Path)
+	assert.Contains(t, string(operations[1].Body), password)
+	assert.NotContains(t, operations[1].String(), password)
+	assert.Contains(t, operations[1].String(), `PUT /profiles/PK {"profile_id":"PK","name":"Kids"`)
+
+	assert.Equal(t, http.MethodDelete, operations[2].Method)
+	assert.Equal(t, "/profiles/PK/groups/42?force=1", operations[2].Path)
+
+	client.ClearPlannedOperations()
+	assert.Empty(t, client.PlannedOperations())
+}
+
+func TestWithDryRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	requests := 0
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	ctx := context.Background()
+	_, err := client.CreateProfile(ctx, CreateProfileParams{Name: "Kids"}, WithDryRun(true))
+	require.NoError(t, err)
+	assert.Equal(t, 0, requests)
+	assert.Len(t, client.PlannedOperations(), 1)
+
+	_, err = client.CreateProfile(ctx, CreateProfileParams{Name: "Kids"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	dryRun, err := client.With(DryRun(true))
+	require.NoError(t, err)
+	_, err = dryRun.CreateProfile(ctx, CreateProfileParams{Name: "Kids"}, WithDryRun(false))
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	_, err = dryRun.CreateProfile(ctx, CreateProfileParams{Name: "Kids"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	// derived clients record into the same list
+	assert.Len(t, client.PlannedOperations(), 2)
+}
@@ -0,0 +1,427 @@
+package controld
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownValue is returned when parsing a value which is not one of the
+// values of an enum type. Values decoded from the API are kept as is instead,
+// so that values added to the API after this version of the package, e.g. a
+// new IconName, do not fail the calls; IsValid reports them.
+var ErrUnknownValue = errors.New("unknown value")
+
+// enum describes the values of an enum type and their names.
+type enum[T ~int | ~string] struct {
+	typeName string
+	values   []T
+	names    []string
+}
+
+func (e enum[T]) name(v T) (string, bool) {
+	i := slices.Index(e.values, v)
+	if i < 0 {
+		return "", false
+	}
+	return e.names[i], true
+}
+
+func (e enum[T]) valid(v T) bool {
+	return slices.Contains(e.values, v)
+}
+
+// parse returns the value named s, ignoring case.
+func (e enum[T]) parse(s string) (T, error) {
+	for i, name := range e.names {
+		if strings.EqualFold(name, s) {
+			return e.values[i], nil
+		}
+	}
+	var zero T
+	return zero, e.unknown(s)
+}
+
+func (e enum[T]) unknown(s string) error {
+	return fmt.Errorf("%w for %s: %q", ErrUnknownValue, e.typeName, s)
+}
+
+// intEnum is an enum encoded as a number by the API.
+type intEnum[T ~int] struct {
+	enum[T]
+}
+
+func (e intEnum[T]) string(v T) string {
+	if name, ok := e.name(v); ok {
+		return name
+	}
+	return fmt.Sprintf("%s(%d)", e.typeName, int(v))
+}
+
+// parse returns the value named s, ignoring case, or numbered s.
+func (e intEnum[T]) parse(s string) (T, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if v := T(n); e.valid(v) {
+			return v, nil
+		}
+		return 0, e.unknown(s)
+	}
+	return e.enum.parse(s)
+}
+
+func (e intEnum[T]) marshalText(v T) ([]byte, error) {
+	name, ok := e.name(v)
+	if !ok {
+		return nil, e.unknown(strconv.Itoa(int(v)))
+	}
+	return []byte(name), nil
+}
+
+// unmarshalJSON decodes a number, as sent by the API, or a name. Unknown
+// numbers are kept, unknown names are an error. null leaves v untouched.
+func (e intEnum[T]) unmarshalJSON(data []byte, v *T) error {
+	text, null, err := jsonText(data)
+	if err != nil || null {
+		return err
+	}
+	if n, err := strconv.Atoi(text); err == nil {
+		*v = T(n)
+		return nil
+	}
+	parsed, err := e.enum.parse(text)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// stringEnum is an enum whose names are its values.
+type stringEnum[T ~string] struct {
+	enum[T]
+}
+
+func newStringEnum[T ~string](typeName string, values ...T) stringEnum[T] {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = string(v)
+	}
+	return stringEnum[T]{enum[T]{typeName: typeName, values: values, names: names}}
+}
+
+// unmarshalText parses text, the empty text being the zero value.
+func (e stringEnum[T]) unmarshalText(text []byte, v *T) error {
+	if len(text) == 0 {
+		*v = ""
+		return nil
+	}
+	parsed, err := e.parse(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// unmarshalJSON decodes a string, keeping unknown values. null leaves v
+// untouched.
+func (e stringEnum[T]) unmarshalJSON(data []byte, v *T) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	if parsed, err := e.parse(text); err == nil {
+		*v = parsed
+	} else {
+		*v = T(text)
+	}
+	return nil
+}
+
+// DoType is the action of a rule, a service or the default rule.
+type DoType int
+
+const (
+	Block    DoType = 0
+	Bypass   DoType = 1
+	Spoof    DoType = 2
+	Redirect DoType = 3
+)
+
+var doTypes = intEnum[DoType]{enum[DoType]{
+	typeName: "DoType",
+	values:   []DoType{Block, Bypass, Spoof, Redirect},
+	names:    []string{"block", "bypass", "spoof", "redirect"},
+}}
+
+// DoTypes returns all the known DoType values.
+func DoTypes() []DoType {
+	return slices.Clone(doTypes.values)
+}
+
+// ParseDoType returns the DoType named s, e.g. "redirect", or numbered s.
+func ParseDoType(s string) (DoType, error) {
+	return doTypes.parse(s)
+}
+
+func (d DoType) String() string {
+	return doTypes.string(d)
+}
+
+func (d DoType) IsValid() bool {
+	return doTypes.valid(d)
+}
+
+func (d DoType) MarshalText() ([]byte, error) {
+	return doTypes.marshalText(d)
+}
+
+func (d *DoType) UnmarshalText(text []byte) error {
+	v, err := doTypes.parse(string(text))
+	if err == nil {
+		*d = v
+	}
+	return err
+}
+
+// MarshalJSON encodes d as a number, as expected by the API.
+func (d DoType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int(d))
+}
+
+func (d *DoType) UnmarshalJSON(data []byte) error {
+	return doTypes.unmarshalJSON(data, d)
+}
+
+// DeviceStatus is the status of a device.
+type DeviceStatus int
+
+const (
+	Pending      DeviceStatus = 0
+	Active       DeviceStatus = 1
+	SoftDisabled DeviceStatus = 2
+	HardDisabled DeviceStatus = 3
+)
+
+var deviceStatuses = intEnum[DeviceStatus]{enum[DeviceStatus]{
+	typeName: "DeviceStatus",
+	values:   []DeviceStatus{Pending, Active, SoftDisabled, HardDisabled},
+	names:    []string{"pending", "active", "soft_disabled", "hard_disabled"},
+}}
+
+// DeviceStatuses returns all the known DeviceStatus values.
+func DeviceStatuses() []DeviceStatus {
+	return slices.Clone(deviceStatuses.values)
+}
+
+// ParseDeviceStatus returns the DeviceStatus named s, e.g. "soft_disabled", or
+// numbered s.
+func ParseDeviceStatus(s string) (DeviceStatus, error) {
+	return deviceStatuses.parse(s)
+}
+
+func (s DeviceStatus) String() string {
+	return deviceStatuses.string(s)
+}
+
+func (s DeviceStatus) IsValid() bool {
+	return deviceStatuses.valid(s)
+}
+
+func (s DeviceStatus) MarshalText() ([]byte, error) {
+	return deviceStatuses.marshalText(s)
+}
+
+func (s *DeviceStatus) UnmarshalText(text []byte) error {
+	v, err := deviceStatuses.parse(string(text))
+	if err == nil {
+		*s = v
+	}
+	return err
+}
+
+// MarshalJSON encodes s as a number, as expected by the API.
+func (s DeviceStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int(s))
+}
+
+func (s *DeviceStatus) UnmarshalJSON(data []byte) error {
+	return deviceStatuses.unmarshalJSON(data, s)
+}
+
+// AnalyticsLevel is the level of analytics logged for a device.
+type AnalyticsLevel int
+
+const (
+	Off   AnalyticsLevel = 0
+	Basic AnalyticsLevel = 1
+	Full  AnalyticsLevel = 2
+)
+
+var analyticsLevels = intEnum[AnalyticsLevel]{enum[AnalyticsLevel]{
+	typeName: "AnalyticsLevel",
+	values:   []AnalyticsLevel{Off, Basic, Full},
+	names:    []string{"off", "basic", "full"},
+}}
+
+// AnalyticsLevels returns all the known AnalyticsLevel values.
+func AnalyticsLevels() []AnalyticsLevel {
+	return slices.Clone(analyticsLevels.values)
+}
+
+// ParseAnalyticsLevel returns the AnalyticsLevel named s, e.g. "full", or
+// numbered s.
+func ParseAnalyticsLevel(s string) (AnalyticsLevel, error) {
+	return analyticsLevels.parse(s)
+}
+
+func (l AnalyticsLevel) String() string {
+	return analyticsLevels.string(l)
+}
+
+func (l AnalyticsLevel) IsValid() bool {
+	return analyticsLevels.valid(l)
+}
+
+func (l AnalyticsLevel) MarshalText() ([]byte, error) {
+	return analyticsLevels.marshalText(l)
+}
+
+func (l *AnalyticsLevel) UnmarshalText(text []byte) error {
+	v, err := analyticsLevels.parse(string(text))
+	if err == nil {
+		*l = v
+	}
+	return err
+}
+
+// MarshalJSON encodes l as a number, as expected by the API.
+func (l AnalyticsLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int(l))
+}
+
+func (l *AnalyticsLevel) UnmarshalJSON(data []byte) error {
+	return analyticsLevels.unmarshalJSON(data, l)
+}
+
+// IconName is the icon of a device, which also tells its type.
+type IconName string
+
+const (
+	DesktopWindows    IconName = "desktop-windows"
+	DesktopMac        IconName = "desktop-mac"
+	DesktopLinux      IconName = "desktop-linux"
+	MobileIOS         IconName = "mobile-ios"
+	MobileAndroid     IconName = "mobile-android"
+	BrowserChrome     IconName = "browser-chrome"
+	BrowserFirefox    IconName = "browser-firefox"
+	BrowserEdge       IconName = "browser-edge"
+	BrowserBrave      IconName = "browser-brave"
+	BrowserOther      IconName = "browser-other"
+	TVApple           IconName = "tv-apple"
+	TVAndroid         IconName = "tv-android"
+	TVFireTV          IconName = "tv-firetv"
+	TVSamsung         IconName = "tv-samsung"
+	TVOther           IconName = "tv"
+	RouterAsus        IconName = "router-asus"
+	RouterDDWRT       IconName = "router-ddwrt"
+	RouterFirewalla   IconName = "router-firewalla"
+	RouterFreshTomato IconName = "router-freshtomato"
+	RouterGLiNET      IconName = "router-glinet"
+	RouterOpenWRT     IconName = "router-openwrt"
+	RouterOPNsense    IconName = "router-opnsense"
+	RouterPfSense     IconName = "router-pfsense"
+	RouterSynology    IconName = "router-synology"
+	RouterUbiquiti    IconName = "router-ubiquiti"
+	RouterWindows     IconName = "router-windows"
+	RouterLinux       IconName = "router-linux"
+	RouterOther       IconName = "router"
+)
+
+var iconNames = newStringEnum("IconName",
+	DesktopWindows, DesktopMac, DesktopLinux, MobileIOS, MobileAndroid,
+	BrowserChrome, BrowserFirefox, BrowserEdge, BrowserBrave, BrowserOther,
+	TVApple, TVAndroid, TVFireTV, TVSamsung, TVOther,
+	RouterAsus, RouterDDWRT, RouterFirewalla, RouterFreshTomato, RouterGLiNET, RouterOpenWRT, RouterOPNsense,
+	RouterPfSense, RouterSynology, RouterUbiquiti, RouterWindows, RouterLinux, RouterOther,
+)
+
+// IconNames returns all the known IconName values.
+func IconNames() []IconName {
+	return slices.Clone(iconNames.values)
+}
+
+// ParseIconName returns the IconName s, ignoring case.
+func ParseIconName(s string) (IconName, error) {
+	return iconNames.parse(s)
+}
+
+func (n IconName) String() string {
+	return string(n)
+}
+
+func (n IconName) IsValid() bool {
+	return iconNames.valid(n)
+}
+
+func (n IconName) MarshalText() ([]byte, error) {
+	return []byte(n), nil
+}
+
+func (n *IconName) UnmarshalText(text []byte) error {
+	return iconNames.unmarshalText(text, n)
+}
+
+// UnmarshalJSON decodes n, keeping icons unknown to this package.
+func (n *IconName) UnmarshalJSON(data []byte) error {
+	return iconNames.unmarshalJSON(data, n)
+}
+
+// ProfileOptionType is the kind of value of a profile option.
+type ProfileOptionType string
+
+const (
+	Dropdown ProfileOptionType = "dropdown"
+	Field    ProfileOptionType = "field"
+	Toggle   ProfileOptionType = "toggle"
+)
+
+var profileOptionTypes = newStringEnum("ProfileOptionType", Dropdown, Field, Toggle)
+
+// ProfileOptionTypes returns all the known ProfileOptionType values.
+func ProfileOptionTypes() []ProfileOptionType {
+	return slices.Clone(profileOptionTypes.values)
+}
+
+// ParseProfileOptionType returns the ProfileOptionType s, ignoring case.
+func ParseProfileOptionType(s string) (ProfileOptionType, error) {
+	return profileOptionTypes.parse(s)
+}
+
+func (t ProfileOptionType) String() string {
+	return string(t)
+}
+
+func (t ProfileOptionType) IsValid() bool {
+	return profileOptionTypes.valid(t)
+}
+
+func (t ProfileOptionType) MarshalText() ([]byte, error) {
+	return []byte(t), nil
+}
+
+func (t *ProfileOptionType) UnmarshalText(text []byte) error {
+	return profileOptionTypes.unmarshalText(text, t)
+}
+
+// UnmarshalJSON decodes t, keeping types unknown to this package.
+func (t *ProfileOptionType) UnmarshalJSON(data []byte) error {
+	return profileOptionTypes.unmarshalJSON(data, t)
+}
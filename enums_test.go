@@ -0,0 +1,113 @@
+package controld
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDoType(t *testing.T) {
+	for _, input := range []string{"redirect", "Redirect", "3"} {
+		do, err := ParseDoType(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, Redirect, do, input)
+	}
+
+	_, err := ParseDoType("teleport")
+	assert.ErrorIs(t, err, ErrUnknownValue)
+	assert.EqualError(t, err, `unknown value for DoType: "teleport"`)
+	_, err = ParseDoType("7")
+	assert.ErrorIs(t, err, ErrUnknownValue)
+}
+
+func TestIntEnumStrings(t *testing.T) {
+	assert.Equal(t, "spoof", Spoof.String())
+	assert.Equal(t, "soft_disabled", SoftDisabled.String())
+	assert.Equal(t, "full", Full.String())
+	assert.Equal(t, "DoType(7)", DoType(7).String())
+	assert.False(t, DoType(7).IsValid())
+	assert.True(t, HardDisabled.IsValid())
+
+	_, err := DoType(7).MarshalText()
+	assert.ErrorIs(t, err, ErrUnknownValue)
+}
+
+func TestEnumValues(t *testing.T) {
+	assert.Equal(t, []DoType{Block, Bypass, Spoof, Redirect}, DoTypes())
+	assert.Equal(t, []DeviceStatus{Pending, Active, SoftDisabled, HardDisabled}, DeviceStatuses())
+	assert.Equal(t, []AnalyticsLevel{Off, Basic, Full}, AnalyticsLevels())
+	assert.Equal(t, []ProfileOptionType{Dropdown, Field, Toggle}, ProfileOptionTypes())
+	assert.Len(t, IconNames(), 28)
+	for _, icon := range IconNames() {
+		parsed, err := ParseIconName(icon.String())
+		require.NoError(t, err)
+		assert.Equal(t, icon, parsed)
+	}
+
+	// the lists are copies
+	DoTypes()[0] = Redirect
+	assert.Equal(t, Block, DoTypes()[0])
+}
+
+func TestEnumJSON(t *testing.T) {
+	type config struct {
+		Do     DoType            `json:"do"`
+		Status DeviceStatus      `json:"status"`
+		Stats  *AnalyticsLevel   `json:"stats"`
+		Icon   IconName          `json:"icon"`
+		Type   ProfileOptionType `json:"type"`
+	}
+
+	// the API sends numbers, config files may use names
+	for _, input := range []string{
+		`{"do": 3, "status": 2, "stats": 1, "icon": "router-openwrt", "type": "toggle"}`,
+		`{"do": "redirect", "status": "soft_disabled", "stats": "basic", "icon": "router-openwrt", "type": "toggle"}`,
+	} {
+		var c config
+		require.NoError(t, json.Unmarshal([]byte(input), &c), input)
+		basic := Basic
+		assert.Equal(t, config{Do: Redirect, Status: SoftDisabled, Stats: &basic, Icon: RouterOpenWRT, Type: Toggle}, c)
+
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"do": 3, "status": 2, "stats": 1, "icon": "router-openwrt", "type": "toggle"}`, string(data))
+	}
+
+	// values added to the API later are kept
+	var c config
+	require.NoError(t, json.Unmarshal([]byte(`{"do": 9, "status": 7, "icon": "toaster", "type": "slider"}`), &c))
+	assert.Equal(t, config{Do: 9, Status: 7, Icon: "toaster", Type: "slider"}, c)
+	assert.False(t, c.Do.IsValid())
+	assert.False(t, c.Status.IsValid())
+	assert.False(t, c.Icon.IsValid())
+	assert.False(t, c.Type.IsValid())
+
+	// names are only accepted if known
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"status": "gone"}`), &c), ErrUnknownValue)
+	assert.ErrorIs(t, c.Icon.UnmarshalText([]byte("toaster")), ErrUnknownValue)
+}
+
+func TestUnknownEnumValuesInResponses(t *testing.T) {
+	var r ListDevicesResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"body": {"devices": [{"PK": "device1", "icon": "router-new", "status": 4}]}, "success": true}`), &r))
+	require.Len(t, r.Body.Devices, 1)
+	device := r.Body.Devices[0]
+	require.NotNil(t, device.Icon)
+	assert.Equal(t, IconName("router-new"), *device.Icon)
+	assert.False(t, device.Icon.IsValid())
+	assert.Equal(t, DeviceStatus(4), device.Status)
+	assert.Equal(t, "DeviceStatus(4)", device.Status.String())
+}
+
+func TestEnumTextMaps(t *testing.T) {
+	// TextMarshaler lets names be used as keys, e.g. in config files
+	var actions map[string]DoType
+	require.NoError(t, json.Unmarshal([]byte(`{"example.com": "block", "example.org": 1}`), &actions))
+	assert.Equal(t, map[string]DoType{"example.com": Block, "example.org": Bypass}, actions)
+
+	var byStatus map[DeviceStatus]int
+	require.NoError(t, json.Unmarshal([]byte(`{"active": 2, "pending": 1}`), &byStatus))
+	assert.Equal(t, map[DeviceStatus]int{Active: 2, Pending: 1}, byStatus)
+}
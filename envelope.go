@@ -0,0 +1,61 @@
+package controld
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Envelope is the JSON document wrapping every API response: the result in
+// body, an optional human-readable message and the success flag and error.
+type Envelope[T any] struct {
+	Body    T      `json:"body"`
+	Message string `json:"message"`
+	Response
+}
+
+// unmarshalEnvelope decodes the envelope of an API response.
+func unmarshalEnvelope[T any](res []byte) (Envelope[T], error) {
+	var r Envelope[T]
+	if err := json.Unmarshal(res, &r); err != nil {
+		return Envelope[T]{}, fmt.Errorf("%s: %w", errUnmarshalError, err)
+	}
+	return r, nil
+}
+
+// DeleteResult is the result of a call deleting resources.
+type DeleteResult struct {
+	// Message is the confirmation sent by the API, e.g. "Device has been
+	// deleted".
+	Message string
+}
+
+// UpdateResult is the result of a call changing a resource without
+// returning it as a typed value.
+type UpdateResult struct {
+	// Body is the body of the response when it describes the updated state,
+	// e.g. of a filter, decoded into maps, slices and basic values.
+	Body any
+	// Message is the confirmation sent by the API, e.g. "1 IPs added".
+	Message string
+}
+
+// ResponseMeta describes the response to a successful API call, see
+// WithResponseMeta.
+type ResponseMeta struct {
+	StatusCode int
+	Header     http.Header
+	// Message is the message of the response envelope, if any.
+	Message string
+}
+
+// set fills meta from res.
+func (meta *ResponseMeta) set(res *APIResponse) {
+	*meta = ResponseMeta{StatusCode: res.StatusCode, Header: res.Headers}
+	var envelope struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(res.Body, &envelope) == nil {
+		meta.Message = envelope.Message
+	}
+}
@@ -0,0 +1,54 @@
+package controld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalEnvelope(t *testing.T) {
+	r, err := unmarshalEnvelope[ListDevicesBody]([]byte(`{"body": {"devices": [{"PK": "device1"}]}, "success": true, "message": "ok"}`))
+	require.NoError(t, err)
+	assert.True(t, r.Success)
+	assert.Equal(t, "ok", r.Message)
+	require.Len(t, r.Body.Devices, 1)
+	assert.Equal(t, "device1", r.Body.Devices[0].PK)
+
+	// the endpoint response types are envelopes
+	var _ ListDevicesResponse = r
+
+	_, err = unmarshalEnvelope[ListDevicesBody]([]byte(`{"body": []`))
+	assert.ErrorContains(t, err, errUnmarshalError)
+}
+
+func TestWithResponseMeta(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/devices/device1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "42")
+		fmt.Fprint(w, `{"body": {"PK": "device1", "name": "laptop"}, "success": true, "message": "Device updated"}`)
+	})
+	mux.HandleFunc("/devices/unknown", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"success": false, "error": {"message": "Device not found", "code": 404}}`)
+	})
+
+	var meta ResponseMeta
+	device, err := client.UpdateDevice(context.Background(), UpdateDeviceParams{DeviceID: "device1", Name: Set("laptop")}, WithResponseMeta(&meta))
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", device.Name)
+	assert.Equal(t, http.StatusOK, meta.StatusCode)
+	assert.Equal(t, "42", meta.Header.Get("X-Request-Id"))
+	assert.Equal(t, "Device updated", meta.Message)
+
+	var failed ResponseMeta
+	_, err = client.UpdateDevice(context.Background(), UpdateDeviceParams{DeviceID: "unknown", Name: Set("laptop")}, WithResponseMeta(&failed))
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.Zero(t, failed, "meta is only filled for successful calls")
+}
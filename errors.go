@@ -1,7 +1,12 @@
 package controld
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+
+	"github.com/goccy/go-json"
 )
 
 const (
@@ -11,7 +16,6 @@ const (
 	errMarshalError         = "error marshalling the object"
 	errUnmarshalError       = "error unmarshalling the JSON response"
 	errTypeError            = "error verifying the type of the response"
-	errUnmarshalErrorBody   = "error unmarshalling the JSON response error body"
 )
 
 type ErrorType string
@@ -22,6 +26,22 @@ const (
 	ErrorTypeAuthorization  ErrorType = "authorization"
 	ErrorTypeNotFound       ErrorType = "not_found"
 	ErrorTypeRateLimit      ErrorType = "rate_limit"
+	ErrorTypeService        ErrorType = "service"
+	ErrorTypeValidation     ErrorType = "validation"
+	ErrorTypeConflict       ErrorType = "conflict"
+	ErrorTypeCircuitOpen    ErrorType = "circuit_open"
+)
+
+// Sentinel errors matching the typed errors returned for API error responses
+// with errors.Is, e.g. errors.Is(err, controld.ErrNotFound).
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServiceError = errors.New("service error")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
 )
 
 type Error struct {
@@ -33,6 +53,28 @@ type Error struct {
 
 	// Errors is all of the error messages and codes, combined.
 	Error ResponseInfo
+
+	// Method is the HTTP method of the failed request.
+	Method string
+
+	// URL is the URL of the failed request.
+	URL string
+
+	// Attempts is the number of attempts made before giving up.
+	Attempts int
+
+	// Body is the raw body of the error response.
+	Body []byte
+
+	// Fields holds the field-level details of a validation error, when the
+	// API returns them.
+	Fields []FieldError
+}
+
+// FieldError describes why the value of a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // RequestError is for 4xx errors that we encounter not covered elsewhere
@@ -42,7 +84,22 @@ type RequestError struct {
 }
 
 func (e RequestError) Error() string {
-	return e.controldError.Error.Message
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e RequestError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e RequestError) Details() *Error {
+	return e.controldError
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e RequestError) Is(target error) bool {
+	return e.controldError.is(target)
 }
 
 func (e RequestError) InternalErrorCodeIs(code int) bool {
@@ -66,7 +123,22 @@ type RatelimitError struct {
 }
 
 func (e RatelimitError) Error() string {
-	return e.controldError.Error.Message
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e RatelimitError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e RatelimitError) Details() *Error {
+	return e.controldError
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e RatelimitError) Is(target error) bool {
+	return e.controldError.is(target)
 }
 
 func (e RatelimitError) InternalErrorCodeIs(code int) bool {
@@ -89,7 +161,22 @@ type ServiceError struct {
 }
 
 func (e ServiceError) Error() string {
-	return e.controldError.Error.Message
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e ServiceError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e ServiceError) Details() *Error {
+	return e.controldError
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e ServiceError) Is(target error) bool {
+	return e.controldError.is(target)
 }
 
 func (e ServiceError) InternalErrorCodeIs(code int) bool {
@@ -106,13 +193,29 @@ func NewServiceError(e *Error) ServiceError {
 	}
 }
 
-// AuthenticationError is for HTTP 401 responses.
+// AuthenticationError is for HTTP 401 responses, where the API token is
+// missing, invalid or revoked.
 type AuthenticationError struct {
 	controldError *Error
 }
 
 func (e AuthenticationError) Error() string {
-	return e.controldError.Error.Message
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e AuthenticationError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e AuthenticationError) Details() *Error {
+	return e.controldError
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e AuthenticationError) Is(target error) bool {
+	return e.controldError.is(target)
 }
 
 func (e AuthenticationError) InternalErrorCodeIs(code int) bool {
@@ -129,13 +232,29 @@ func NewAuthenticationError(e *Error) AuthenticationError {
 	}
 }
 
-// AuthorizationError is for HTTP 403 responses.
+// AuthorizationError is for HTTP 403 responses, where the API token is valid
+// but not allowed to perform the request.
 type AuthorizationError struct {
 	controldError *Error
 }
 
 func (e AuthorizationError) Error() string {
-	return e.controldError.Error.Message
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e AuthorizationError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e AuthorizationError) Details() *Error {
+	return e.controldError
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e AuthorizationError) Is(target error) bool {
+	return e.controldError.is(target)
 }
 
 func (e AuthorizationError) InternalErrorCodeIs(code int) bool {
@@ -158,7 +277,22 @@ type NotFoundError struct {
 }
 
 func (e NotFoundError) Error() string {
-	return e.controldError.Error.Message
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e NotFoundError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e NotFoundError) Details() *Error {
+	return e.controldError
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e NotFoundError) Is(target error) bool {
+	return e.controldError.is(target)
 }
 
 func (e NotFoundError) InternalErrorCodeIs(code int) bool {
@@ -175,6 +309,90 @@ func NewNotFoundError(e *Error) NotFoundError {
 	}
 }
 
+// ValidationError is for HTTP 400 and 422 responses, where the API rejected
+// the request parameters. Details().Fields holds the rejected fields when the
+// API returns them.
+type ValidationError struct {
+	controldError *Error
+}
+
+func (e ValidationError) Error() string {
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e ValidationError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e ValidationError) Details() *Error {
+	return e.controldError
+}
+
+// Fields returns the field-level details of the error, if any.
+func (e ValidationError) Fields() []FieldError {
+	return e.controldError.Fields
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e ValidationError) Is(target error) bool {
+	return e.controldError.is(target)
+}
+
+func (e ValidationError) InternalErrorCodeIs(code int) bool {
+	return e.controldError.InternalErrorCodeIs(code)
+}
+
+func (e ValidationError) Type() ErrorType {
+	return e.controldError.Type
+}
+
+func NewValidationError(e *Error) ValidationError {
+	return ValidationError{
+		controldError: e,
+	}
+}
+
+// ConflictError is for HTTP 409 responses, e.g. when creating a resource which
+// already exists.
+type ConflictError struct {
+	controldError *Error
+}
+
+func (e ConflictError) Error() string {
+	return e.controldError.message()
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e ConflictError) StatusCode() int {
+	return e.controldError.StatusCode
+}
+
+// Details returns the request and response details of the error.
+func (e ConflictError) Details() *Error {
+	return e.controldError
+}
+
+// Is reports whether target is the sentinel error matching e.
+func (e ConflictError) Is(target error) bool {
+	return e.controldError.is(target)
+}
+
+func (e ConflictError) InternalErrorCodeIs(code int) bool {
+	return e.controldError.InternalErrorCodeIs(code)
+}
+
+func (e ConflictError) Type() ErrorType {
+	return e.controldError.Type
+}
+
+func NewConflictError(e *Error) ConflictError {
+	return ConflictError{
+		controldError: e,
+	}
+}
+
 // ClientError returns a boolean whether or not the raised error was caused by
 // something client side.
 func (e *Error) ClientError() bool {
@@ -193,3 +411,140 @@ func (e *Error) ClientRateLimited() bool {
 func (e *Error) InternalErrorCodeIs(code int) bool {
 	return e.StatusCode == code
 }
+
+// message describes the error with the request that caused it.
+func (e *Error) message() string {
+	msg := e.Error.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Method == "" {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, msg)
+	}
+	return fmt.Sprintf("%s %s: HTTP %d: %s", e.Method, e.URL, e.StatusCode, msg)
+}
+
+// is reports whether target is the sentinel error matching the status code of
+// e.
+func (e *Error) is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServiceError:
+		return e.StatusCode >= http.StatusInternalServerError
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	default:
+		return false
+	}
+}
+
+// newResponseError maps an API error response to a typed error. The JSON error
+// body is parsed when there is one. The mapping is:
+//
+//	400, 422  ValidationError      ErrorTypeValidation      ErrValidation
+//	401       AuthenticationError  ErrorTypeAuthentication  ErrUnauthorized
+//	403       AuthorizationError   ErrorTypeAuthorization   ErrForbidden
+//	404       NotFoundError        ErrorTypeNotFound        ErrNotFound
+//	409       ConflictError        ErrorTypeConflict        ErrConflict
+//	429       RatelimitError       ErrorTypeRateLimit       ErrRateLimited
+//	5xx       ServiceError         ErrorTypeService         ErrServiceError
+//	other 4xx RequestError         ErrorTypeRequest
+func newResponseError(method, url string, statusCode int, body []byte, attempts int) error {
+	err := &Error{
+		StatusCode: statusCode,
+		Method:     method,
+		URL:        url,
+		Attempts:   attempts,
+		Body:       body,
+	}
+
+	errBody := &Response{}
+	if json.Unmarshal(body, errBody) == nil {
+		err.Error = errBody.Error
+	}
+
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		if err.Error.Message == "" {
+			err.Error.Message = errInternalServiceError
+		}
+		err.Type = ErrorTypeService
+		return &ServiceError{controldError: err}
+	case statusCode == http.StatusBadRequest, statusCode == http.StatusUnprocessableEntity:
+		err.Type = ErrorTypeValidation
+		err.Fields = parseFieldErrors(body)
+		return &ValidationError{controldError: err}
+	case statusCode == http.StatusUnauthorized:
+		err.Type = ErrorTypeAuthentication
+		return &AuthenticationError{controldError: err}
+	case statusCode == http.StatusForbidden:
+		err.Type = ErrorTypeAuthorization
+		return &AuthorizationError{controldError: err}
+	case statusCode == http.StatusNotFound:
+		err.Type = ErrorTypeNotFound
+		return &NotFoundError{controldError: err}
+	case statusCode == http.StatusConflict:
+		err.Type = ErrorTypeConflict
+		return &ConflictError{controldError: err}
+	case statusCode == http.StatusTooManyRequests:
+		err.Type = ErrorTypeRateLimit
+		return &RatelimitError{controldError: err}
+	default:
+		err.Type = ErrorTypeRequest
+		return &RequestError{controldError: err}
+	}
+}
+
+// parseFieldErrors reads the field-level details of a validation error body.
+// The API sends them in error.fields, either as a list of FieldError or as an
+// object mapping each field to a message or a list of messages.
+func parseFieldErrors(body []byte) []FieldError {
+	var errBody struct {
+		Error struct {
+			Fields json.RawMessage `json:"fields"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &errBody) != nil || len(errBody.Error.Fields) == 0 {
+		return nil
+	}
+	raw := errBody.Error.Fields
+
+	var list []FieldError
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+
+	var byField map[string]json.RawMessage
+	if json.Unmarshal(raw, &byField) != nil {
+		return nil
+	}
+	fields := make([]string, 0, len(byField))
+	for field := range byField {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	var fieldErrors []FieldError
+	for _, field := range fields {
+		var message string
+		var messages []string
+		switch {
+		case json.Unmarshal(byField[field], &message) == nil:
+			fieldErrors = append(fieldErrors, FieldError{Field: field, Message: message})
+		case json.Unmarshal(byField[field], &messages) == nil:
+			for _, message := range messages {
+				fieldErrors = append(fieldErrors, FieldError{Field: field, Message: message})
+			}
+		}
+	}
+	return fieldErrors
+}
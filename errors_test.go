@@ -0,0 +1,173 @@
+package controld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorsIs(t *testing.T) {
+	notFound := newResponseError(http.MethodGet, "https://api.controld.com/devices/x", http.StatusNotFound, nil, 1)
+	assert.ErrorIs(t, notFound, ErrNotFound)
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", notFound), ErrNotFound)
+	assert.NotErrorIs(t, notFound, ErrRateLimited)
+
+	assert.ErrorIs(t, newResponseError(http.MethodGet, "", http.StatusUnauthorized, nil, 1), ErrUnauthorized)
+	assert.ErrorIs(t, newResponseError(http.MethodGet, "", http.StatusForbidden, nil, 1), ErrForbidden)
+	assert.ErrorIs(t, newResponseError(http.MethodGet, "", http.StatusTooManyRequests, nil, 1), ErrRateLimited)
+	assert.ErrorIs(t, newResponseError(http.MethodGet, "", http.StatusBadGateway, nil, 1), ErrServiceError)
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := newResponseError(http.MethodDelete, "https://api.controld.com/devices/x", http.StatusNotFound,
+		[]byte(`{"success": false, "error": {"message": "Device not found", "code": 40401}}`), 1)
+	assert.EqualError(t, err, "DELETE https://api.controld.com/devices/x: HTTP 404: Device not found")
+
+	err = newResponseError(http.MethodGet, "https://api.controld.com/devices", http.StatusBadRequest, []byte(`<html>`), 1)
+	assert.EqualError(t, err, "GET https://api.controld.com/devices: HTTP 400: Bad Request")
+
+	assert.EqualError(t, NewNotFoundError(&Error{StatusCode: http.StatusNotFound}), "HTTP 404: Not Found")
+}
+
+func TestServiceErrorDetails(t *testing.T) {
+	setup(UsingRetryPolicy(2, 0, 0))
+	defer teardown()
+
+	body := `{"success": false, "error": {"message": "Database unavailable", "code": 50301}}`
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, body)
+	})
+
+	_, err := client.ListDevices(context.Background())
+	require.ErrorIs(t, err, ErrServiceError)
+
+	var serviceErr *ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, http.StatusServiceUnavailable, serviceErr.StatusCode())
+	assert.Equal(t, ErrorTypeService, serviceErr.Type())
+
+	details := serviceErr.Details()
+	assert.Equal(t, http.MethodGet, details.Method)
+	assert.Equal(t, server.URL+"/devices", details.URL)
+	assert.Equal(t, 3, details.Attempts)
+	assert.Equal(t, body, string(details.Body))
+	assert.Equal(t, "Database unavailable", details.Error.Message)
+	assert.Equal(t, 50301, details.Error.Code)
+}
+
+func TestServiceErrorWithoutBody(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := client.ListDevices(context.Background())
+	var serviceErr *ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, errInternalServiceError, serviceErr.Details().Error.Message)
+}
+
+func TestRateLimitErrorAfterRetries(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrRateLimited)
+	var rateLimitErr *RatelimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.True(t, rateLimitErr.Details().ClientRateLimited())
+}
+
+func TestResponseErrorMapping(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		errType    ErrorType
+		target     any
+		sentinel   error
+	}{
+		{http.StatusBadRequest, ErrorTypeValidation, new(*ValidationError), ErrValidation},
+		{http.StatusUnauthorized, ErrorTypeAuthentication, new(*AuthenticationError), ErrUnauthorized},
+		{http.StatusForbidden, ErrorTypeAuthorization, new(*AuthorizationError), ErrForbidden},
+		{http.StatusNotFound, ErrorTypeNotFound, new(*NotFoundError), ErrNotFound},
+		{http.StatusMethodNotAllowed, ErrorTypeRequest, new(*RequestError), nil},
+		{http.StatusConflict, ErrorTypeConflict, new(*ConflictError), ErrConflict},
+		{http.StatusUnprocessableEntity, ErrorTypeValidation, new(*ValidationError), ErrValidation},
+		{http.StatusTooManyRequests, ErrorTypeRateLimit, new(*RatelimitError), ErrRateLimited},
+		{http.StatusInternalServerError, ErrorTypeService, new(*ServiceError), ErrServiceError},
+		{http.StatusServiceUnavailable, ErrorTypeService, new(*ServiceError), ErrServiceError},
+	}
+
+	sentinels := []error{ErrValidation, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict, ErrRateLimited, ErrServiceError}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			err := newResponseError(http.MethodGet, "", tt.statusCode, nil, 1)
+
+			require.ErrorAs(t, err, tt.target)
+			assert.Equal(t, tt.errType, errorTypeOf(err))
+			for _, sentinel := range sentinels {
+				assert.Equal(t, sentinel == tt.sentinel, errors.Is(err, sentinel), sentinel.Error())
+			}
+		})
+	}
+}
+
+func TestValidationErrorFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []FieldError
+	}{
+		{
+			name: "list",
+			body: `{"success": false, "error": {"message": "Invalid input", "fields": [{"field": "name", "message": "Name is required"}]}}`,
+			fields: []FieldError{
+				{Field: "name", Message: "Name is required"},
+			},
+		},
+		{
+			name: "object",
+			body: `{"success": false, "error": {"message": "Invalid input", "fields": {"status": "Invalid status", "name": ["Name is required", "Name is too long"]}}}`,
+			fields: []FieldError{
+				{Field: "name", Message: "Name is required"},
+				{Field: "name", Message: "Name is too long"},
+				{Field: "status", Message: "Invalid status"},
+			},
+		},
+		{
+			name: "none",
+			body: `{"success": false, "error": {"message": "Invalid input"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := client.CreateProfile(context.Background(), CreateProfileParams{})
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, "Invalid input", validationErr.Details().Error.Message)
+			assert.Equal(t, tt.fields, validationErr.Fields())
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package controld
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	authorizationHeaderRegexp = regexp.MustCompile(`(?mi)^(Authorization:[ \t]*)[^\r\n]*`)
+	passwordFieldRegexp       = regexp.MustCompile(`("(?i:password)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// printfWriter adapts a Logger to the io.Writer expected by slog handlers.
+type printfWriter struct {
+	logger Logger
+}
+
+func (w printfWriter) Write(p []byte) (int, error) {
+	w.logger.Printf("%s", strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// newLogAdapter returns the slog.Logger writing events of level Info and above
+// to logger, without the time which the Logger adds itself. Events are not
+// formatted at all for a standard logger which discards its output, such as
+// the default one.
+func newLogAdapter(logger Logger) *slog.Logger {
+	if l, ok := logger.(*log.Logger); ok && l.Writer() == io.Discard {
+		return slog.New(slog.DiscardHandler)
+	}
+	return slog.New(slog.NewTextHandler(printfWriter{logger}, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+// log emits a levelled event. Events go to the slog.Logger set with UsingSlog,
+// otherwise events of level Info and above are written to the Logger set with
+// UsingLogger.
+func (api *API) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	logger := api.slogger
+	if logger == nil {
+		logger = api.logAdapter
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// dump logs a request or response dump produced in debug mode, after removing
+// secrets from it. Without a slog.Logger dumps go to the standard logger.
+func (api *API) dump(ctx context.Context, msg string, dump []byte, token string) {
+	redactedDump := redact(string(dump), token)
+	if api.slogger != nil {
+		api.slogger.LogAttrs(ctx, slog.LevelDebug, msg, slog.String("dump", redactedDump))
+		return
+	}
+	log.Printf("\n%s", redactedDump)
+}
+
+// redact removes the API token, the Authorization header and password fields
+// of JSON bodies from s.
+func redact(s string, token string) string {
+	s = authorizationHeaderRegexp.ReplaceAllString(s, "${1}"+redacted)
+	s = passwordFieldRegexp.ReplaceAllString(s, `${1}"`+redacted+`"`)
+	if token != "" {
+		s = strings.ReplaceAll(s, token, redacted)
+	}
+	return s
+}
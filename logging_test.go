@@ -0,0 +1,102 @@
+package controld
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	dump := "PUT /profiles/PK HTTP/1.1\r\n" +
+		"Host: api.controld.com\r\n" +
+		"Authorization: Bearer api.1377\r\n" +
+		"\r\n" +
+		`{"profile_id":"PK","password": "s3cr\"et","Password":"other","name":"profile"}`
+
+	actual := redact(dump, "api.1377")
+
+	assert.NotContains(t, actual, "api.1377")
+	assert.NotContains(t, actual, "s3cr")
+	assert.NotContains(t, actual, "other")
+	assert.Contains(t, actual, "Authorization: [REDACTED]\r\n")
+	assert.Contains(t, actual, `"password": "[REDACTED]"`)
+	assert.Contains(t, actual, `"name":"profile"`)
+}
+
+func TestUsingSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	setup(UsingSlog(logger), Debug(true), UsingRetryPolicy(1, 0, 0))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/profiles/PK", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	password := "s3cret"
+	_, err := client.UpdateProfile(context.Background(), UpdateProfileParams{ProfileID: "PK", Password: Set(password)})
+	require.NoError(t, err)
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		messages = append(messages, event["msg"].(string))
+	}
+	assert.Equal(t, []string{
+		"request started", "request dump", "response dump", "request finished",
+		"retrying request",
+		"request started", "request dump", "response dump", "request finished",
+	}, messages)
+	assert.NotContains(t, buf.String(), "api.1377")
+	assert.NotContains(t, buf.String(), password)
+}
+
+func TestUsingLogger(t *testing.T) {
+	var buf bytes.Buffer
+	setup(UsingLogger(log.New(&buf, "", log.LstdFlags)), UsingRetryPolicy(1, 0, 0))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"devices": []}, "success": true}`)
+	})
+
+	_, err := client.ListDevices(context.Background())
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1, "only events of level Info and above are logged")
+	assert.Contains(t, lines[0], `level=INFO msg="retrying request"`)
+	assert.NotContains(t, lines[0], "time=", "the Logger adds the time itself")
+}
+
+func TestDefaultLoggerSkipsFormatting(t *testing.T) {
+	setup()
+	defer teardown()
+
+	assert.False(t, client.logAdapter.Enabled(context.Background(), slog.LevelError))
+}
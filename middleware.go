@@ -0,0 +1,48 @@
+package controld
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// OutgoingRequest describes a single attempt of an API call as seen by
+// middleware. Middleware may modify it before passing it on, e.g. to add
+// headers or rewrite the URI.
+type OutgoingRequest struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// URI is the path and query of the request, relative to the API BaseURL.
+	URI string
+
+	// Params are the parameters given by the caller, before serialization.
+	Params interface{}
+
+	// Body is the serialized request body, nil when there is none.
+	Body io.Reader
+
+	// Header holds the request specific headers. The client headers,
+	// authorization and user agent are added after every middleware ran.
+	Header http.Header
+
+	// token is the API token of the attempt.
+	token string
+}
+
+// RequestHandler sends an OutgoingRequest to the API and returns its response.
+// The caller is responsible for closing the response body.
+type RequestHandler func(ctx context.Context, req *OutgoingRequest) (*http.Response, error)
+
+// Middleware wraps a RequestHandler to intercept every attempt made by the
+// client, including retries.
+type Middleware func(next RequestHandler) RequestHandler
+
+// chainMiddleware wraps handler so that the first middleware is the outermost
+// one.
+func chainMiddleware(handler RequestHandler, middleware []Middleware) RequestHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
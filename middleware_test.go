@@ -0,0 +1,71 @@
+package controld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareOrderAndVisibility(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next RequestHandler) RequestHandler {
+			return func(ctx context.Context, req *OutgoingRequest) (*http.Response, error) {
+				calls = append(calls, name+" before "+req.Method+" "+req.URI)
+				resp, err := next(ctx, req)
+				if assert.NoError(t, err) {
+					calls = append(calls, fmt.Sprintf("%s after %d", name, resp.StatusCode))
+				}
+				return resp, err
+			}
+		}
+	}
+
+	setup(UsingMiddleware(trace("outer"), trace("inner")))
+	defer teardown()
+
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	_, err := client.ListProfiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"outer before GET /profiles",
+		"inner before GET /profiles",
+		"inner after 200",
+		"outer after 200",
+	}, calls)
+}
+
+func TestMiddlewareMutatesRequest(t *testing.T) {
+	var params interface{}
+	mutate := func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, req *OutgoingRequest) (*http.Response, error) {
+			params = req.Params
+			req.Header.Set("X-Audit-ID", "audit")
+			req.URI = "/profiles/rewritten"
+			return next(ctx, req)
+		}
+	}
+
+	setup(UsingMiddleware(mutate))
+	defer teardown()
+
+	mux.HandleFunc("/profiles/rewritten", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "audit", r.Header.Get("X-Audit-ID"))
+		assert.Equal(t, "Bearer api.1377", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	createParams := CreateProfileParams{Name: "profile"}
+	_, err := client.CreateProfile(context.Background(), createParams)
+	require.NoError(t, err)
+	assert.Equal(t, createParams, params)
+}
@@ -17,10 +17,7 @@ type IP struct {
 	Pop     string `json:"pop"`
 }
 
-type ListIPResponse struct {
-	Body IP `json:"body"`
-	Response
-}
+type ListIPResponse = Envelope[IP]
 
 type Location struct {
 	Lat  float64 `json:"lat"`
@@ -47,15 +44,12 @@ type ListNetworkBody struct {
 	CurrentPop string    `json:"current_pop"`
 }
 
-type ListNetworkResponse struct {
-	Body ListNetworkBody `json:"body"`
-	Response
-}
+type ListNetworkResponse = Envelope[ListNetworkBody]
 
-func (api *API) ListIP(ctx context.Context) (IP, error) {
+func (api *API) ListIP(ctx context.Context, opts ...ReqOption) (IP, error) {
 	uri := buildURI("/ip", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return IP{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -66,10 +60,10 @@ func (api *API) ListIP(ctx context.Context) (IP, error) {
 	return r.Body, nil
 }
 
-func (api *API) ListNetwork(ctx context.Context) ([]Network, error) {
+func (api *API) ListNetwork(ctx context.Context, opts ...ReqOption) ([]Network, error) {
 	uri := buildURI("/network", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Network{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -0,0 +1,121 @@
+package controld
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// errorTypeOther labels errors which did not come from an API response, such
+// as transport failures or canceled contexts.
+const errorTypeOther ErrorType = "other"
+
+// RequestObservation describes a completed API call, including all of its
+// attempts.
+type RequestObservation struct {
+	// Method is the HTTP method of the call.
+	Method string
+
+	// Route is the URI template of the call with identifiers replaced by
+	// "{id}", e.g. "/profiles/{id}/rules".
+	Route string
+
+	// StatusCode is the HTTP status code of the last response, 0 if no
+	// response was received.
+	StatusCode int
+
+	// ErrorType classifies the error returned to the caller, empty on
+	// success. Errors that did not come from an API response are labelled
+	// "other".
+	ErrorType ErrorType
+
+	// Duration is the time spent in the call, including retries and rate
+	// limiting.
+	Duration time.Duration
+
+	// Attempts is the number of HTTP requests sent.
+	Attempts int
+
+	// RateLimitWait is the time spent waiting for the rate limiter.
+	RateLimitWait time.Duration
+
+	// Coalesced reports whether the call shared the in-flight request of an
+	// identical call instead of sending its own, see UsingRequestCoalescing.
+	// Attempts is then 0.
+	Coalesced bool
+}
+
+// Retries returns the number of attempts made after the first one.
+func (o RequestObservation) Retries() int {
+	if o.Attempts == 0 {
+		return 0
+	}
+	return o.Attempts - 1
+}
+
+// Observer is notified of every API call made by a client, e.g. to collect
+// metrics. ObserveRequest is called synchronously once the call completed and
+// must be safe for concurrent use.
+type Observer interface {
+	ObserveRequest(observation RequestObservation)
+}
+
+// callStats collects what happened during the attempts of a single call.
+type callStats struct {
+	attempts      int
+	statusCode    int
+	rateLimitWait time.Duration
+}
+
+// routeSegments are the static path segments of the API, every other segment
+// is considered an identifier.
+var routeSegments = map[string]bool{
+	"access":     true,
+	"analytics":  true,
+	"categories": true,
+	"default":    true,
+	"devices":    true,
+	"endpoints":  true,
+	"external":   true,
+	"filter":     true,
+	"filters":    true,
+	"groups":     true,
+	"ip":         true,
+	"levels":     true,
+	"network":    true,
+	"options":    true,
+	"profiles":   true,
+	"rules":      true,
+	"services":   true,
+	"types":      true,
+	"users":      true,
+}
+
+// routeTemplate returns the route of uri with identifiers replaced by "{id}"
+// so that it can be used as a low cardinality label.
+func routeTemplate(uri string) string {
+	path := uri
+	if u, err := url.Parse(uri); err == nil {
+		path = u.Path
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if segment != "" && !routeSegments[segment] {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// errorTypeOf returns the ErrorType of err, empty when err is nil.
+func errorTypeOf(err error) ErrorType {
+	if err == nil {
+		return ""
+	}
+	var typed interface{ Type() ErrorType }
+	if errors.As(err, &typed) && typed.Type() != "" {
+		return typed.Type()
+	}
+	return errorTypeOther
+}
@@ -0,0 +1,89 @@
+package controld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	mu           sync.Mutex
+	observations []RequestObservation
+}
+
+func (o *recordingObserver) ObserveRequest(observation RequestObservation) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observations = append(o.observations, observation)
+}
+
+func TestRouteTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/devices":                      "/devices",
+		"/devices/types":                "/devices/types",
+		"/devices/deviceID":             "/devices/{id}",
+		"/profiles/PK/rules":            "/profiles/{id}/rules",
+		"/profiles/PK/rules/folderID":   "/profiles/{id}/rules/{id}",
+		"/profiles/PK/filters/external": "/profiles/{id}/filters/external",
+		"/services/categories/audio":    "/services/categories/{id}",
+		"/access?device_id=deviceID":    "/access",
+	}
+	for uri, want := range tests {
+		assert.Equal(t, want, routeTemplate(uri), uri)
+	}
+}
+
+func TestErrorTypeOf(t *testing.T) {
+	assert.Equal(t, ErrorType(""), errorTypeOf(nil))
+	assert.Equal(t, ErrorTypeNotFound, errorTypeOf(fmt.Errorf("wrapped: %w", &NotFoundError{controldError: &Error{Type: ErrorTypeNotFound}})))
+	assert.Equal(t, errorTypeOther, errorTypeOf(errors.New("connection refused")))
+}
+
+func TestUsingObserver(t *testing.T) {
+	observer := &recordingObserver{}
+	setup(UsingObserver(observer), UsingRetryPolicy(1, 0, 0))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/profiles/PK/rules/folderID", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"rules": []}, "success": true}`)
+	})
+	mux.HandleFunc("/profiles/unknown/rules/folderID", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"success": false, "error": {"message": "Profile not found", "code": 404}}`)
+	})
+
+	_, err := client.ListProfileCustomRules(context.Background(), ListProfileCustomRulesParams{ProfileID: "PK", FolderID: "folderID"})
+	require.NoError(t, err)
+	_, err = client.ListProfileCustomRules(context.Background(), ListProfileCustomRulesParams{ProfileID: "unknown", FolderID: "folderID"})
+	require.Error(t, err)
+
+	require.Len(t, observer.observations, 2)
+
+	success := observer.observations[0]
+	assert.Equal(t, http.MethodGet, success.Method)
+	assert.Equal(t, "/profiles/{id}/rules/{id}", success.Route)
+	assert.Equal(t, http.StatusOK, success.StatusCode)
+	assert.Equal(t, ErrorType(""), success.ErrorType)
+	assert.Equal(t, 2, success.Attempts)
+	assert.Equal(t, 1, success.Retries())
+	assert.Positive(t, success.Duration)
+
+	failure := observer.observations[1]
+	assert.Equal(t, http.StatusNotFound, failure.StatusCode)
+	assert.Equal(t, ErrorTypeNotFound, failure.ErrorType)
+	assert.Equal(t, 0, failure.Retries())
+}
@@ -0,0 +1,101 @@
+package controld
+
+import (
+	"encoding/json"
+)
+
+// Optional is a field of the params of an update call, which can be left
+// unset, so that the API leaves the value alone, set to null, to clear the
+// value, or set to a value. The zero Optional is unset.
+//
+//	params := controld.UpdateProfileParams{
+//		ProfileID:   "profile1",
+//		Name:        controld.Set("Kids"),    // set
+//		LockMessage: controld.Null[string](), // clear
+//		// the other fields are left alone
+//	}
+//
+// Fields of type Optional are tagged omitzero, which omits them from the
+// request body when they are unset.
+type Optional[T any] struct {
+	value T
+	state optionalState
+}
+
+type optionalState uint8
+
+const (
+	optionalUnset optionalState = iota
+	optionalNull
+	optionalValue
+)
+
+// Set returns an Optional set to v.
+func Set[T any](v T) Optional[T] {
+	return Optional[T]{value: v, state: optionalValue}
+}
+
+// Null returns an Optional set to null, clearing the value.
+func Null[T any]() Optional[T] {
+	return Optional[T]{state: optionalNull}
+}
+
+// FromPtr returns an Optional set to *p, or unset if p is nil.
+func FromPtr[T any](p *T) Optional[T] {
+	if p == nil {
+		return Optional[T]{}
+	}
+	return Set(*p)
+}
+
+// Get returns the value of o and whether o is set to a value.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.state == optionalValue
+}
+
+// Ptr returns a pointer to the value of o, or nil if o is not set to a value.
+func (o Optional[T]) Ptr() *T {
+	if o.state != optionalValue {
+		return nil
+	}
+	v := o.value
+	return &v
+}
+
+// IsSet reports whether o is set, to null or to a value.
+func (o Optional[T]) IsSet() bool {
+	return o.state != optionalUnset
+}
+
+// IsNull reports whether o is set to null.
+func (o Optional[T]) IsNull() bool {
+	return o.state == optionalNull
+}
+
+// IsZero reports whether o is unset, for the omitzero option of
+// encoding/json.
+func (o Optional[T]) IsZero() bool {
+	return o.state == optionalUnset
+}
+
+// MarshalJSON encodes the value of o, or null if o is not set to a value.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if o.state != optionalValue {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON sets o to null or to the decoded value.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = Null[T]()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Set(v)
+	return nil
+}
@@ -0,0 +1,142 @@
+package controld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptional(t *testing.T) {
+	var unset Optional[string]
+	assert.False(t, unset.IsSet())
+	assert.True(t, unset.IsZero())
+	assert.Nil(t, unset.Ptr())
+
+	null := Null[string]()
+	assert.True(t, null.IsSet())
+	assert.True(t, null.IsNull())
+	_, ok := null.Get()
+	assert.False(t, ok)
+	assert.Nil(t, null.Ptr())
+
+	value := Set("Kids")
+	v, ok := value.Get()
+	assert.True(t, ok)
+	assert.Equal(t, "Kids", v)
+	assert.Equal(t, "Kids", *value.Ptr())
+	assert.False(t, value.IsNull())
+
+	assert.Equal(t, unset, FromPtr[string](nil))
+	assert.Equal(t, value, FromPtr(&v))
+}
+
+func TestOptionalJSON(t *testing.T) {
+	var v struct {
+		Unset Optional[int] `json:"unset,omitzero"`
+		Null  Optional[int] `json:"null,omitzero"`
+		Value Optional[int] `json:"value,omitzero"`
+		Zero  Optional[int] `json:"zero,omitzero"`
+	}
+	v.Null = Null[int]()
+	v.Value = Set(42)
+	v.Zero = Set(0)
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"null":null,"value":42,"zero":0}`, string(data))
+
+	var decoded struct {
+		Unset Optional[int] `json:"unset"`
+		Null  Optional[int] `json:"null"`
+		Value Optional[int] `json:"value"`
+		Zero  Optional[int] `json:"zero"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.False(t, decoded.Unset.IsSet())
+	assert.True(t, decoded.Null.IsNull())
+	assert.Equal(t, Set(42), decoded.Value)
+	assert.Equal(t, Set(0), decoded.Zero)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"value": "many"}`), &decoded))
+}
+
+func TestUpdateParamsJSON(t *testing.T) {
+	tests := map[string]struct {
+		params any
+		want   string
+	}{
+		"profile left alone": {
+			params: UpdateProfileParams{ProfileID: "PK"},
+			want:   `{"profile_id":"PK"}`,
+		},
+		"profile set and cleared": {
+			params: UpdateProfileParams{ProfileID: "PK", Name: Set("Kids"), DisableTTL: Set(0), LockMessage: Null[string](), Password: Null[string]()},
+			want:   `{"profile_id":"PK","name":"Kids","disable_ttl":0,"lock_message":null,"password":null}`,
+		},
+		"device": {
+			params: UpdateDeviceParams{DeviceID: "device1", Stats: Set(Off), LearnIP: Set(IntBool(false)), Desc: Null[string](), Status: Set(SoftDisabled)},
+			want:   `{"id":"device1","stats":0,"learn_ip":0,"desc":null,"status":2}`,
+		},
+		"profile option": {
+			params: UpdateProfilesOption{ProfileID: "PK", Name: "ai_malware", Status: true},
+			want:   `{"profile_id":"PK","name":"ai_malware","status":1}`,
+		},
+		"profile option reset": {
+			params: UpdateProfilesOption{ProfileID: "PK", Name: "ai_malware", Status: true, Value: Null[string]()},
+			want:   `{"profile_id":"PK","name":"ai_malware","status":1,"value":null}`,
+		},
+		"custom rule": {
+			params: UpdateProfileCustomRuleParams{ProfileID: "PK", Do: Redirect, Status: true, Via: Set("LAX"), Group: Set(0), Hostnames: []string{"example.com"}},
+			want:   `{"profile_id":"PK","do":3,"status":1,"via":"LAX","group":0,"hostnames":["example.com"]}`,
+		},
+		"default rule": {
+			params: UpdateProfileDefaultRuleParams{ProfileID: "PK", Do: Bypass, Status: true},
+			want:   `{"profile_id":"PK","do":1,"status":1}`,
+		},
+		"rule folder": {
+			params: UpdateProfileRuleFolderParams{ProfileID: "PK", FolderID: "1", Do: Set(Block), Via: Null[string]()},
+			want:   `{"profile_id":"PK","folder":"1","do":0,"via":null}`,
+		},
+		"service": {
+			params: UpdateProfileServiceParams{ProfileID: "PK", Service: "spotify", Do: Spoof, Status: true, ViaV6: Null[string]()},
+			want:   `{"profile_id":"PK","service":"spotify","do":2,"status":1,"via_v6":null}`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, err := newRequestBody(tt.params, defaultMaxReplayBodySize)
+			require.NoError(t, err)
+			r, err := body()
+			require.NoError(t, err)
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestUpdateProfileSendsOnlySetFields(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/profiles/PK", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"profile_id":"PK","lock_status":0,"lock_message":null}`, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	_, err := client.UpdateProfile(context.Background(), UpdateProfileParams{
+		ProfileID:   "PK",
+		LockStatus:  Set(IntBool(false)),
+		LockMessage: Null[string](),
+	})
+	require.NoError(t, err)
+}
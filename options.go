@@ -1,16 +1,18 @@
 package controld
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
-
-	"golang.org/x/time/rate"
 )
 
 // Option is a functional option for configuring the API client.
 type Option func(*API) error
 
-// HTTPClient accepts a custom *http.Client for making API calls.
+// HTTPClient accepts a custom *http.Client for making API calls. By default a
+// client with dial, TLS handshake and response header timeouts is used.
 func HTTPClient(client *http.Client) Option {
 	return func(api *API) error {
 		api.httpClient = client
@@ -18,24 +20,45 @@ func HTTPClient(client *http.Client) Option {
 	}
 }
 
+// DefaultTimeout bounds every call, including its retries, to timeout when
+// its context has no deadline. WithTimeout overrides it for a single call.
+// Defaults to 2 minutes; 0 disables it.
+func DefaultTimeout(timeout time.Duration) Option {
+	return func(api *API) error {
+		if timeout < 0 {
+			return errors.New("default timeout must not be negative")
+		}
+		api.defaultTimeout = timeout
+		return nil
+	}
+}
+
 // Headers allows you to set custom HTTP headers when making API calls (e.g. for
 // satisfying HTTP proxies, or for debugging).
 func Headers(headers http.Header) Option {
 	return func(api *API) error {
-		api.headers = headers
+		api.headers = headers.Clone()
 		return nil
 	}
 }
 
 // UsingRateLimit applies a non-default rate limit to client API requests
-// If not specified the default of 4rps will be applied.
+// If not specified the default of 4rps will be applied, shared by every client
+// created with the same API token.
 func UsingRateLimit(rps float64) Option {
 	return func(api *API) error {
-		// because ratelimiter doesnt do any windowing
-		// setting burst makes it difficult to enforce a fixed rate
-		// so setting it equal to 1 this effectively disables bursting
-		// this doesn't check for sensible values, ultimately the api will enforce that the value is ok
-		api.rateLimiter = rate.NewLimiter(rate.Limit(rps), 1)
+		api.rateLimiter = NewRateLimiter(rps)
+		api.customRateLimiter = true
+		return nil
+	}
+}
+
+// UsingRateLimiter paces client API requests with limiter. Pass the same
+// limiter to several clients for them to share a single budget.
+func UsingRateLimiter(limiter RateLimiter) Option {
+	return func(api *API) error {
+		api.rateLimiter = limiter
+		api.customRateLimiter = true
 		return nil
 	}
 }
@@ -45,20 +68,127 @@ func UsingRateLimit(rps float64) Option {
 func UsingRetryPolicy(maxRetries int, minRetryDelaySecs int, maxRetryDelaySecs int) Option {
 	// seconds is very granular for a minimum delay - but this is only in case of failure
 	return func(api *API) error {
-		api.retryPolicy = RetryPolicy{
-			MaxRetries:    maxRetries,
-			MinRetryDelay: time.Duration(minRetryDelaySecs) * time.Second,
-			MaxRetryDelay: time.Duration(maxRetryDelaySecs) * time.Second,
+		api.retryPolicy.MaxRetries = maxRetries
+		api.retryPolicy.MinRetryDelay = time.Duration(minRetryDelaySecs) * time.Second
+		api.retryPolicy.MaxRetryDelay = time.Duration(maxRetryDelaySecs) * time.Second
+		return nil
+	}
+}
+
+// UsingRetryNonIdempotent allows POST and PATCH requests to be retried after a
+// server error or a transport failure. Only enable it if creating the same
+// resource twice is acceptable.
+func UsingRetryNonIdempotent(enabled bool) Option {
+	return func(api *API) error {
+		api.retryPolicy.RetryNonIdempotent = enabled
+		return nil
+	}
+}
+
+// UsingRetryCheck replaces the default retry classification with shouldRetry,
+// which decides for every failed attempt whether it can be retried.
+func UsingRetryCheck(shouldRetry func(method string, resp *http.Response, err error) bool) Option {
+	return func(api *API) error {
+		api.retryPolicy.ShouldRetry = shouldRetry
+		return nil
+	}
+}
+
+// UsingMiddleware appends middleware wrapping every request made by the
+// client. Middleware run in the order they are given, the first one being the
+// outermost, and see every attempt including retries.
+func UsingMiddleware(middleware ...Middleware) Option {
+	return func(api *API) error {
+		api.middleware = append(api.middleware, middleware...)
+		return nil
+	}
+}
+
+// UsingMaxReplayBodySize sets how many bytes of a streamed request body (an
+// io.Reader passed to Raw) are buffered so that the body can be sent again on
+// retries. Larger bodies are sent once and a retry fails with
+// ErrBodyNotReplayable. Defaults to 10 MiB.
+func UsingMaxReplayBodySize(size int64) Option {
+	return func(api *API) error {
+		if size < 0 {
+			return fmt.Errorf("max replay body size must not be negative, got %d", size)
 		}
+		api.maxReplayBodySize = size
 		return nil
 	}
 }
 
 // UsingLogger can be set if you want to get log output from this API instance
-// By default no log output is emitted.
+// By default no log output is emitted. Events of level Info and above, e.g.
+// retries, are written as slog text lines without their time.
 func UsingLogger(logger Logger) Option {
 	return func(api *API) error {
-		api.logger = logger
+		api.logAdapter = newLogAdapter(logger)
+		return nil
+	}
+}
+
+// UsingSlog sends levelled log events (request start and finish, retries and
+// rate limiter waits) to logger. Debug dumps are also written to logger, at
+// debug level, instead of the standard logger. Secrets are redacted from
+// dumps.
+func UsingSlog(logger *slog.Logger) Option {
+	return func(api *API) error {
+		api.slogger = logger
+		return nil
+	}
+}
+
+// UsingObserver notifies observer of every API call made by the client, e.g.
+// to collect metrics. See PrometheusObserver for a ready to use
+// implementation.
+func UsingObserver(observer Observer) Option {
+	return func(api *API) error {
+		api.observer = observer
+		return nil
+	}
+}
+
+// UsingRequestCoalescing makes concurrent identical GET requests (same URL
+// and headers) share a single in-flight HTTP call and its result, so that
+// they spend a single rate limiter token. CoalescedRequests reports how many
+// calls were deduplicated.
+func UsingRequestCoalescing(enabled bool) Option {
+	return func(api *API) error {
+		api.flights = nil
+		if enabled {
+			api.flights = newFlightGroup()
+		}
+		return nil
+	}
+}
+
+// UsingCache caches for ttl the responses of the catalogue endpoints
+// (ListServiceCategories, ListServices, ListDeviceType, ListLogLevels,
+// ListStorageRegions, ListNetwork and ListProfilesOptions) in cache. Cached
+// responses of a resource are dropped whenever the client successfully
+// mutates the same resource.
+func UsingCache(cache Cache, ttl time.Duration) Option {
+	return func(api *API) error {
+		api.cache = cache
+		api.cacheTTL = ttl
+		return nil
+	}
+}
+
+// UsingCircuitBreaker makes the client fail fast with ErrCircuitOpen, without
+// contacting the API, once the share of failed attempts (HTTP 5xx responses,
+// timeouts and network errors) reaches settings.FailureRate. After
+// settings.OpenTimeout a few probe requests are let through, which close the
+// circuit again when they succeed. State changes are logged. Clients derived
+// with With share the breaker.
+func UsingCircuitBreaker(settings CircuitBreakerSettings) Option {
+	return func(api *API) error {
+		breaker, err := newCircuitBreaker(settings)
+		if err != nil {
+			return err
+		}
+		api.breaker = breaker
 		return nil
 	}
 }
@@ -74,6 +204,51 @@ func UserAgent(userAgent string) Option {
 	}
 }
 
+// APIToken replaces the API token used to authenticate requests. It is meant
+// to derive a client with With, New already takes the token. The client then
+// uses the rate limiter shared by the clients of token, unless its limiter
+// was set with UsingRateLimit or UsingRateLimiter.
+func APIToken(token string) Option {
+	return func(api *API) error {
+		if token == "" {
+			return errors.New(errEmptyAPIToken)
+		}
+		api.APIToken = token
+		api.tokenSource = StaticTokenSource(token)
+		if !api.customRateLimiter {
+			api.rateLimiter = sharedRateLimiter(token)
+		}
+		return nil
+	}
+}
+
+// UsingTokenSource replaces the API token of the client with tokenSource,
+// which is asked for a token before every request. Like NewWithTokenSource,
+// the client then gets a rate limiter of its own, unless its limiter was set
+// with UsingRateLimit or UsingRateLimiter.
+func UsingTokenSource(tokenSource TokenSource) Option {
+	return func(api *API) error {
+		if tokenSource == nil {
+			return errors.New(errEmptyAPIToken)
+		}
+		api.tokenSource = tokenSource
+		if !api.customRateLimiter {
+			api.rateLimiter = NewRateLimiter(defaultRateLimit)
+		}
+		return nil
+	}
+}
+
+// UsingOrganization makes every request of the client act on behalf of the
+// sub-organization orgID, through the X-Force-Org-Id header. The API token
+// must belong to a parent organization of orgID.
+func UsingOrganization(orgID string) Option {
+	return func(api *API) error {
+		api.organizationID = orgID
+		return nil
+	}
+}
+
 // BaseURL allows you to override the default HTTP base URL used for API calls.
 func BaseURL(baseURL string) Option {
 	return func(api *API) error {
@@ -82,6 +257,8 @@ func BaseURL(baseURL string) Option {
 	}
 }
 
+// Debug dumps every request and response, with the API token and passwords
+// redacted, to the standard logger or to the slog.Logger set with UsingSlog.
 func Debug(debug bool) Option {
 	return func(api *API) error {
 		api.Debug = debug
@@ -89,6 +266,18 @@ func Debug(debug bool) Option {
 	}
 }
 
+// DryRun makes the client record every mutating call (any method but GET and
+// HEAD) as a PlannedOperation instead of sending it, so that a change script
+// can be reviewed before running it for real. Recorded calls return an empty
+// successful response; retrieve them with PlannedOperations. Reads are still
+// sent.
+func DryRun(enabled bool) Option {
+	return func(api *API) error {
+		api.dryRun = enabled
+		return nil
+	}
+}
+
 // parseOptions parses the supplied options functions and returns a configured
 // *API instance.
 func (api *API) parseOptions(opts ...Option) error {
@@ -0,0 +1,46 @@
+package controld
+
+import (
+	"context"
+)
+
+// organizationHeader makes the API act on behalf of a sub-organization of the
+// organization owning the API token.
+const organizationHeader = "X-Force-Org-Id"
+
+// OrganizationResult holds the outcome of an operation run for a single
+// organization by ForEachOrganization.
+type OrganizationResult[T any] struct {
+	OrganizationID string
+	Value          T
+	Err            error
+}
+
+// ForEachOrganization runs fn once per organization in orgIDs, in order, with
+// a client impersonating that organization, and collects the per-organization
+// results and errors. Organizations not processed because ctx is done report
+// the context error.
+//
+//	devices := controld.ForEachOrganization(ctx, api, orgIDs, func(ctx context.Context, org *controld.API) ([]controld.Device, error) {
+//		return org.ListDevices(ctx)
+//	})
+func ForEachOrganization[T any](ctx context.Context, api *API, orgIDs []string, fn func(ctx context.Context, org *API) (T, error)) []OrganizationResult[T] {
+	results := make([]OrganizationResult[T], 0, len(orgIDs))
+	for _, orgID := range orgIDs {
+		result := OrganizationResult[T]{OrganizationID: orgID}
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		org, err := api.With(UsingOrganization(orgID))
+		if err != nil {
+			result.Err = err
+		} else {
+			result.Value, result.Err = fn(ctx, org)
+		}
+		results = append(results, result)
+	}
+	return results
+}
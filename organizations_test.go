@@ -0,0 +1,74 @@
+package controld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsingOrganization(t *testing.T) {
+	setup(UsingOrganization("org-parent"))
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"body": {"devices": [{"PK": %q}]}, "success": true}`, r.Header.Get("X-Force-Org-Id"))
+	})
+
+	devices, err := client.ListDevices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "org-parent", devices[0].PK)
+
+	devices, err = client.ListDevices(context.Background(), WithOrganization("org-child"))
+	require.NoError(t, err)
+	assert.Equal(t, "org-child", devices[0].PK, "the request organization should take precedence")
+}
+
+func TestForEachOrganization(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		orgID := r.Header.Get("X-Force-Org-Id")
+		if orgID == "org-unknown" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"success": false, "error": {"message": "Forbidden", "code": 403}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"body": {"devices": [{"PK": %q}]}, "success": true}`, orgID)
+	})
+
+	results := ForEachOrganization(context.Background(), client, []string{"org-1", "org-unknown", "org-2"},
+		func(ctx context.Context, org *API) ([]Device, error) {
+			return org.ListDevices(ctx)
+		})
+
+	require.Len(t, results, 3)
+	assert.Equal(t, "org-1", results[0].OrganizationID)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, "org-1", results[0].Value[0].PK)
+	assert.Equal(t, "org-unknown", results[1].OrganizationID)
+	assert.Error(t, results[1].Err)
+	require.NoError(t, results[2].Err)
+	assert.Equal(t, "org-2", results[2].Value[0].PK)
+}
+
+func TestForEachOrganizationCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	api, err := New("api.1377")
+	require.NoError(t, err)
+
+	results := ForEachOrganization(ctx, api, []string{"org-1"}, func(ctx context.Context, org *API) (int, error) {
+		return 0, errors.New("should not be called")
+	})
+	require.Len(t, results, 1)
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+}
@@ -17,10 +17,7 @@ type ListProfilesBody struct {
 	Profiles []Profile `json:"profiles"`
 }
 
-type ListProfilesResponse struct {
-	Body ListProfilesBody `json:"body"`
-	Response
-}
+type ListProfilesResponse = Envelope[ListProfilesBody]
 
 type CreateProfileParams struct {
 	Name           string  `json:"name"`
@@ -31,46 +28,28 @@ type CreateProfileBody struct {
 	Profiles []Profile `json:"profiles"`
 }
 
-type CreateProfileResponse struct {
-	Body ListProfilesBody `json:"body"`
-	Response
-}
+type CreateProfileResponse = Envelope[ListProfilesBody]
 
 type UpdateProfileParams struct {
-	ProfileID   string   `json:"profile_id"`
-	Name        *string  `json:"name"`
-	DisableTTL  *int     `json:"disable_ttl"`
-	LockStatus  *IntBool `json:"lock_status"`
-	LockMessage *string  `json:"lock_message"`
-	Password    *string  `json:"password"`
+	ProfileID   string            `json:"profile_id"`
+	Name        Optional[string]  `json:"name,omitzero"`
+	DisableTTL  Optional[int]     `json:"disable_ttl,omitzero"`
+	LockStatus  Optional[IntBool] `json:"lock_status,omitzero"`
+	LockMessage Optional[string]  `json:"lock_message,omitzero"`
+	Password    Optional[string]  `json:"password,omitzero"`
 }
 
 type UpdateProfileBody struct {
 	Profiles []Profile `json:"profiles"`
 }
 
-type UpdateProfileResponse struct {
-	Body ListProfilesBody `json:"body"`
-	Response
-}
+type UpdateProfileResponse = Envelope[ListProfilesBody]
 
 type DeleteProfileParams struct {
 	ProfileID string `json:"profile_id"`
 }
 
-type DeleteProfileResponse struct {
-	Body    []any  `json:"body"`
-	Message string `json:"message"`
-	Response
-}
-
-type ProfileOptionType string
-
-const (
-	Dropdown ProfileOptionType = "dropdown"
-	Field    ProfileOptionType = "field"
-	Toggle   ProfileOptionType = "toggle"
-)
+type DeleteProfileResponse = Envelope[[]any]
 
 type ProfilesOption struct {
 	PK           string            `json:"PK"`
@@ -85,16 +64,13 @@ type ListProfilesOptionsBody struct {
 	Options []ProfilesOption `json:"options"`
 }
 
-type ListProfilesOptionsResponse struct {
-	Body ListProfilesOptionsBody `json:"body"`
-	Response
-}
+type ListProfilesOptionsResponse = Envelope[ListProfilesOptionsBody]
 
 type UpdateProfilesOption struct {
-	ProfileID string  `json:"profile_id"`
-	Name      string  `json:"name"`
-	Status    IntBool `json:"status"`
-	Value     *string `json:"value"`
+	ProfileID string           `json:"profile_id"`
+	Name      string           `json:"name"`
+	Status    IntBool          `json:"status"`
+	Value     Optional[string] `json:"value,omitzero"`
 }
 
 type UpdateProfilesOptionBody struct {
@@ -102,15 +78,12 @@ type UpdateProfilesOptionBody struct {
 	Response
 }
 
-type UpdateProfilesOptionResponse struct {
-	Body UpdateProfilesOptionBody `json:"body"`
-	Response
-}
+type UpdateProfilesOptionResponse = Envelope[UpdateProfilesOptionBody]
 
-func (api *API) ListProfiles(ctx context.Context) ([]Profile, error) {
+func (api *API) ListProfiles(ctx context.Context, opts ...ReqOption) ([]Profile, error) {
 	uri := buildURI("/profiles", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Profile{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -124,10 +97,10 @@ func (api *API) ListProfiles(ctx context.Context) ([]Profile, error) {
 	return r.Body.Profiles, nil
 }
 
-func (api *API) CreateProfile(ctx context.Context, params CreateProfileParams) ([]Profile, error) {
+func (api *API) CreateProfile(ctx context.Context, params CreateProfileParams, opts ...ReqOption) ([]Profile, error) {
 	uri := buildURI("/profiles", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params, opts...)
 	if err != nil {
 		return []Profile{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -141,14 +114,14 @@ func (api *API) CreateProfile(ctx context.Context, params CreateProfileParams) (
 	return r.Body.Profiles, nil
 }
 
-func (api *API) UpdateProfile(ctx context.Context, params UpdateProfileParams) ([]Profile, error) {
+func (api *API) UpdateProfile(ctx context.Context, params UpdateProfileParams, opts ...ReqOption) ([]Profile, error) {
 	if params.ProfileID == "" {
 		return []Profile{}, fmt.Errorf("update: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
 		return []Profile{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -162,31 +135,29 @@ func (api *API) UpdateProfile(ctx context.Context, params UpdateProfileParams) (
 	return r.Body.Profiles, nil
 }
 
-func (api *API) DeleteProfile(ctx context.Context, params DeleteProfileParams) ([]any, error) {
+func (api *API) DeleteProfile(ctx context.Context, params DeleteProfileParams, opts ...ReqOption) (DeleteResult, error) {
 	if params.ProfileID == "" {
-		return []any{}, fmt.Errorf("delete: no profile ID provided")
+		return DeleteResult{}, fmt.Errorf("delete: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params, opts...)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return DeleteResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r DeleteProfileResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[json.RawMessage](res)
 	if err != nil {
-		return []any{}, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return DeleteResult{}, err
 	}
-	return r.Body, nil
+	return DeleteResult{Message: r.Message}, nil
 }
 
-func (api *API) ListProfilesOptions(ctx context.Context) ([]ProfilesOption, error) {
+func (api *API) ListProfilesOptions(ctx context.Context, opts ...ReqOption) ([]ProfilesOption, error) {
 	uri := buildURI("/profiles/options", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []ProfilesOption{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -200,26 +171,24 @@ func (api *API) ListProfilesOptions(ctx context.Context) ([]ProfilesOption, erro
 	return r.Body.Options, nil
 }
 
-func (api *API) UpdateProfilesOption(ctx context.Context, params UpdateProfilesOption) (any, error) {
+func (api *API) UpdateProfilesOption(ctx context.Context, params UpdateProfilesOption, opts ...ReqOption) (UpdateResult, error) {
 	if params.ProfileID == "" {
-		return nil, fmt.Errorf("update: no profile ID provided")
+		return UpdateResult{}, fmt.Errorf("update: no profile ID provided")
 	}
 	if params.Name == "" {
-		return nil, fmt.Errorf("update: no profile options name provided")
+		return UpdateResult{}, fmt.Errorf("update: no profile options name provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/options/%s", params.ProfileID, params.Name)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return UpdateResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r UpdateProfilesOptionResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[UpdateProfilesOptionBody](res)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return UpdateResult{}, err
 	}
-	return r.Body.Options, nil
+	return UpdateResult{Body: r.Body.Options, Message: r.Message}, nil
 }
@@ -25,10 +25,7 @@ type ListProfileCustomRulesBody struct {
 	Rules []Rule `json:"rules"`
 }
 
-type ListProfileCustomRulesResponse struct {
-	Body ListProfileCustomRulesBody `json:"body"`
-	Response
-}
+type ListProfileCustomRulesResponse = Envelope[ListProfileCustomRulesBody]
 
 type CreateProfileCustomRuleParams struct {
 	ProfileID string   `json:"profile_id"`
@@ -44,41 +41,32 @@ type CreateProfileCustomRuleBody struct {
 	Rules []CustomRule `json:"rules"`
 }
 
-type CreateProfileCustomRuleResponse struct {
-	Body CreateProfileCustomRuleBody `json:"body"`
-	Response
-}
+type CreateProfileCustomRuleResponse = Envelope[CreateProfileCustomRuleBody]
 
 type UpdateProfileCustomRuleParams struct {
-	ProfileID string   `json:"profile_id"`
-	Do        DoType   `json:"do"`
-	Status    IntBool  `json:"status"`
-	Via       *string  `json:"via,omitempty"`
-	ViaV6     *string  `json:"via_v6,omitempty"`
-	Group     *int     `json:"group,omitempty"`
-	Hostnames []string `json:"hostnames"`
+	ProfileID string           `json:"profile_id"`
+	Do        DoType           `json:"do"`
+	Status    IntBool          `json:"status"`
+	Via       Optional[string] `json:"via,omitzero"`
+	ViaV6     Optional[string] `json:"via_v6,omitzero"`
+	Group     Optional[int]    `json:"group,omitzero"`
+	Hostnames []string         `json:"hostnames"`
 }
 
 type UpdateProfileCustomRuleBody struct {
 	Rules []CustomRule `json:"rules"`
 }
 
-type UpdateProfileCustomRuleResponse struct {
-	Body UpdateProfileCustomRuleBody `json:"body"`
-	Response
-}
+type UpdateProfileCustomRuleResponse = Envelope[UpdateProfileCustomRuleBody]
 
 type DeleteProfileCustomRuleParams struct {
 	ProfileID string `json:"profile_id"`
 	Hostname  string `json:"hostname"`
 }
 
-type DeleteProfileCustomRuleResponse struct {
-	Body any `json:"body"`
-	Response
-}
+type DeleteProfileCustomRuleResponse = Envelope[any]
 
-func (api *API) ListProfileCustomRules(ctx context.Context, params ListProfileCustomRulesParams) ([]Rule, error) {
+func (api *API) ListProfileCustomRules(ctx context.Context, params ListProfileCustomRulesParams, opts ...ReqOption) ([]Rule, error) {
 	if params.ProfileID == "" {
 		return []Rule{}, fmt.Errorf("list: no profile ID provided")
 	}
@@ -88,7 +76,7 @@ func (api *API) ListProfileCustomRules(ctx context.Context, params ListProfileCu
 	baseURL := fmt.Sprintf("/profiles/%s/rules/%s", params.ProfileID, params.FolderID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Rule{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -102,14 +90,14 @@ func (api *API) ListProfileCustomRules(ctx context.Context, params ListProfileCu
 	return r.Body.Rules, nil
 }
 
-func (api *API) CreateProfileCustomRule(ctx context.Context, params CreateProfileCustomRuleParams) ([]CustomRule, error) {
+func (api *API) CreateProfileCustomRule(ctx context.Context, params CreateProfileCustomRuleParams, opts ...ReqOption) ([]CustomRule, error) {
 	if params.ProfileID == "" {
 		return []CustomRule{}, fmt.Errorf("create: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/rules", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params, opts...)
 	if err != nil {
 		return []CustomRule{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -123,14 +111,14 @@ func (api *API) CreateProfileCustomRule(ctx context.Context, params CreateProfil
 	return r.Body.Rules, nil
 }
 
-func (api *API) UpdateProfileCustomRule(ctx context.Context, params UpdateProfileCustomRuleParams) ([]CustomRule, error) {
+func (api *API) UpdateProfileCustomRule(ctx context.Context, params UpdateProfileCustomRuleParams, opts ...ReqOption) ([]CustomRule, error) {
 	if params.ProfileID == "" {
 		return []CustomRule{}, fmt.Errorf("update: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/rules", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
 		return []CustomRule{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -144,26 +132,24 @@ func (api *API) UpdateProfileCustomRule(ctx context.Context, params UpdateProfil
 	return r.Body.Rules, nil
 }
 
-func (api *API) DeleteProfileCustomRule(ctx context.Context, params DeleteProfileCustomRuleParams) (any, error) {
+func (api *API) DeleteProfileCustomRule(ctx context.Context, params DeleteProfileCustomRuleParams, opts ...ReqOption) (DeleteResult, error) {
 	if params.ProfileID == "" {
-		return nil, fmt.Errorf("delete: no profile ID provided")
+		return DeleteResult{}, fmt.Errorf("delete: no profile ID provided")
 	}
 	if params.Hostname == "" {
-		return nil, fmt.Errorf("delete: no hostname provided")
+		return DeleteResult{}, fmt.Errorf("delete: no hostname provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/rules/%s", params.ProfileID, params.Hostname)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return DeleteResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r DeleteProfileCustomRuleResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[json.RawMessage](res)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return DeleteResult{}, err
 	}
-	return r.Body, nil
+	return DeleteResult{Message: r.Message}, nil
 }
@@ -200,7 +200,7 @@ func TestDeleteProfileCustomRule(t *testing.T) {
 	mux.HandleFunc(fmt.Sprintf("/profiles/%s/rules/%s", params.ProfileID, params.Hostname), handler)
 	actual, err := client.DeleteProfileCustomRule(context.Background(), params)
 
-	want := []interface{}{}
+	want := DeleteResult{Message: "Custom rule(s) deleted"}
 	if assert.NoError(t, err) {
 		assert.Equal(t, want, actual)
 	}
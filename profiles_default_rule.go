@@ -18,35 +18,29 @@ type ListProfileDefaultRuleBody struct {
 	Default any `json:"default"`
 }
 
-type ListProfileDefaultRuleResponse struct {
-	Body ListProfileDefaultRuleBody `json:"body"`
-	Response
-}
+type ListProfileDefaultRuleResponse = Envelope[ListProfileDefaultRuleBody]
 
 type UpdateProfileDefaultRuleParams struct {
-	ProfileID string  `json:"profile_id"`
-	Do        DoType  `json:"do"`
-	Status    IntBool `json:"status"`
-	Via       *string `json:"via,omitempty"`
+	ProfileID string           `json:"profile_id"`
+	Do        DoType           `json:"do"`
+	Status    IntBool          `json:"status"`
+	Via       Optional[string] `json:"via,omitzero"`
 }
 
 type UpdateProfileDefaultRuleBody struct {
 	Default DefaultRule `json:"default"`
 }
 
-type UpdateProfileDefaultRuleResponse struct {
-	Body UpdateProfileDefaultRuleBody `json:"body"`
-	Response
-}
+type UpdateProfileDefaultRuleResponse = Envelope[UpdateProfileDefaultRuleBody]
 
-func (api *API) ListProfileDefaultRule(ctx context.Context, params ListProfileDefaultRuleParams) (DefaultRule, error) {
+func (api *API) ListProfileDefaultRule(ctx context.Context, params ListProfileDefaultRuleParams, opts ...ReqOption) (DefaultRule, error) {
 	if params.ProfileID == "" {
 		return DefaultRule{}, fmt.Errorf("list: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/default", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return DefaultRule{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -81,14 +75,14 @@ func (api *API) ListProfileDefaultRule(ctx context.Context, params ListProfileDe
 	}
 }
 
-func (api *API) UpdateProfileDefaultRule(ctx context.Context, params UpdateProfileDefaultRuleParams) (DefaultRule, error) {
+func (api *API) UpdateProfileDefaultRule(ctx context.Context, params UpdateProfileDefaultRuleParams, opts ...ReqOption) (DefaultRule, error) {
 	if params.ProfileID == "" {
 		return DefaultRule{}, fmt.Errorf("update: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/default", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
 		return DefaultRule{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -45,10 +45,7 @@ type ListProfileFiltersBody struct {
 	Filters []Filter `json:"filters"`
 }
 
-type ListProfileFiltersResponse struct {
-	Body ListProfileFiltersBody `json:"body"`
-	Response
-}
+type ListProfileFiltersResponse = Envelope[ListProfileFiltersBody]
 
 type UpdateProfileFilterParams struct {
 	ProfileID string  `json:"profile_id"`
@@ -60,19 +57,16 @@ type UpdateProfileFilterBody struct {
 	Filters any `json:"filters"`
 }
 
-type UpdateProfileFilterResponse struct {
-	Body UpdateProfileFilterBody `json:"body"`
-	Response
-}
+type UpdateProfileFilterResponse = Envelope[UpdateProfileFilterBody]
 
-func (api *API) ListProfileNativeFilters(ctx context.Context, params ListProfileFiltersParams) ([]Filter, error) {
+func (api *API) ListProfileNativeFilters(ctx context.Context, params ListProfileFiltersParams, opts ...ReqOption) ([]Filter, error) {
 	if params.ProfileID == "" {
 		return nil, fmt.Errorf("list: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/filters", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Filter{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -86,14 +80,14 @@ func (api *API) ListProfileNativeFilters(ctx context.Context, params ListProfile
 	return r.Body.Filters, nil
 }
 
-func (api *API) ListProfileExternalFilters(ctx context.Context, params ListProfileFiltersParams) ([]Filter, error) {
+func (api *API) ListProfileExternalFilters(ctx context.Context, params ListProfileFiltersParams, opts ...ReqOption) ([]Filter, error) {
 	if params.ProfileID == "" {
 		return nil, fmt.Errorf("list: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/filters/external", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Filter{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -107,23 +101,21 @@ func (api *API) ListProfileExternalFilters(ctx context.Context, params ListProfi
 	return r.Body.Filters, nil
 }
 
-func (api *API) UpdateProfileFilter(ctx context.Context, params UpdateProfileFilterParams) (any, error) {
+func (api *API) UpdateProfileFilter(ctx context.Context, params UpdateProfileFilterParams, opts ...ReqOption) (UpdateResult, error) {
 	if params.ProfileID == "" {
-		return nil, fmt.Errorf("update: no profile ID provided")
+		return UpdateResult{}, fmt.Errorf("update: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/filters/filter/%s", params.ProfileID, params.Filter)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return UpdateResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r UpdateProfileFilterResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[UpdateProfileFilterBody](res)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return UpdateResult{}, err
 	}
-	return r.Body.Filters, nil
+	return UpdateResult{Body: r.Body.Filters, Message: r.Message}, nil
 }
@@ -184,7 +184,7 @@ func TestUpdateProfileFilter(t *testing.T) {
 		},
 	}
 	if assert.NoError(t, err) {
-		assert.Equal(t, want, actual)
+		assert.Equal(t, UpdateResult{Body: want}, actual)
 	}
 
 	_, err = client.UpdateProfileFilter(context.Background(), UpdateProfileFilterParams{
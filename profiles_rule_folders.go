@@ -27,10 +27,7 @@ type ListProfileRuleFoldersBody struct {
 	Groups []Group `json:"groups"`
 }
 
-type ListProfileRuleFoldersResponse struct {
-	Body ListProfileRuleFoldersBody `json:"body"`
-	Response
-}
+type ListProfileRuleFoldersResponse = Envelope[ListProfileRuleFoldersBody]
 
 type CreateProfileRuleFolderParams struct {
 	ProfileID string   `json:"profile_id"`
@@ -44,46 +41,37 @@ type CreateProfileRuleFolderBody struct {
 	Groups []Group `json:"groups"`
 }
 
-type CreateProfileRuleFolderResponse struct {
-	Body CreateProfileRuleFolderBody `json:"body"`
-	Response
-}
+type CreateProfileRuleFolderResponse = Envelope[CreateProfileRuleFolderBody]
 
 type UpdateProfileRuleFolderParams struct {
-	ProfileID string   `json:"profile_id"`
-	FolderID  string   `json:"folder"`
-	Do        *DoType  `json:"do,omitempty"`
-	Via       *string  `json:"via,omitempty"`
-	Status    *IntBool `json:"status,omitempty"`
+	ProfileID string            `json:"profile_id"`
+	FolderID  string            `json:"folder"`
+	Do        Optional[DoType]  `json:"do,omitzero"`
+	Via       Optional[string]  `json:"via,omitzero"`
+	Status    Optional[IntBool] `json:"status,omitzero"`
 }
 
 type UpdateProfileRuleFolderBody struct {
 	Groups []Group `json:"groups"`
 }
 
-type UpdateProfileRuleFolderResponse struct {
-	Body CreateProfileRuleFolderBody `json:"body"`
-	Response
-}
+type UpdateProfileRuleFolderResponse = Envelope[CreateProfileRuleFolderBody]
 
 type DeleteProfileRuleFolderParams struct {
 	ProfileID string `json:"profile_id"`
 	FolderID  string `json:"folder"`
 }
 
-type DeleteProfileRuleFolderResponse struct {
-	Body any `json:"body"`
-	Response
-}
+type DeleteProfileRuleFolderResponse = Envelope[any]
 
-func (api *API) ListProfileRuleFolders(ctx context.Context, params ListProfileRuleFoldersParams) ([]Group, error) {
+func (api *API) ListProfileRuleFolders(ctx context.Context, params ListProfileRuleFoldersParams, opts ...ReqOption) ([]Group, error) {
 	if params.ProfileID == "" {
 		return []Group{}, fmt.Errorf("list: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/groups", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Group{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -97,14 +85,14 @@ func (api *API) ListProfileRuleFolders(ctx context.Context, params ListProfileRu
 	return r.Body.Groups, nil
 }
 
-func (api *API) CreateProfileRuleFolder(ctx context.Context, params CreateProfileRuleFolderParams) ([]Group, error) {
+func (api *API) CreateProfileRuleFolder(ctx context.Context, params CreateProfileRuleFolderParams, opts ...ReqOption) ([]Group, error) {
 	if params.ProfileID == "" {
 		return []Group{}, fmt.Errorf("create: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/groups", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPost, uri, params, opts...)
 	if err != nil {
 		return []Group{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -118,14 +106,14 @@ func (api *API) CreateProfileRuleFolder(ctx context.Context, params CreateProfil
 	return r.Body.Groups, nil
 }
 
-func (api *API) UpdateProfileRuleFolder(ctx context.Context, params UpdateProfileRuleFolderParams) ([]Group, error) {
+func (api *API) UpdateProfileRuleFolder(ctx context.Context, params UpdateProfileRuleFolderParams, opts ...ReqOption) ([]Group, error) {
 	if params.ProfileID == "" {
 		return []Group{}, fmt.Errorf("update: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/groups/%s", params.ProfileID, params.FolderID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
 		return []Group{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -139,23 +127,21 @@ func (api *API) UpdateProfileRuleFolder(ctx context.Context, params UpdateProfil
 	return r.Body.Groups, nil
 }
 
-func (api *API) DeleteProfileRuleFolder(ctx context.Context, params DeleteProfileRuleFolderParams) (any, error) {
+func (api *API) DeleteProfileRuleFolder(ctx context.Context, params DeleteProfileRuleFolderParams, opts ...ReqOption) (DeleteResult, error) {
 	if params.ProfileID == "" {
-		return nil, fmt.Errorf("delete: no profile ID provided")
+		return DeleteResult{}, fmt.Errorf("delete: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/groups/%s", params.ProfileID, params.FolderID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodDelete, uri, params, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errMakeRequestError, err)
+		return DeleteResult{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
 
-	var r DeleteProfileRuleFolderResponse
-
-	err = json.Unmarshal(res, &r)
+	r, err := unmarshalEnvelope[json.RawMessage](res)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errUnmarshalError, err)
+		return DeleteResult{}, err
 	}
-	return r.Body, nil
+	return DeleteResult{Message: r.Message}, nil
 }
@@ -196,7 +196,7 @@ func TestDeleteProfileRuleFolder(t *testing.T) {
 	mux.HandleFunc(fmt.Sprintf("/profiles/%s/groups/%s", params.ProfileID, params.FolderID), handler)
 	actual, err := client.DeleteProfileRuleFolder(context.Background(), params)
 
-	want := []interface{}{}
+	want := DeleteResult{Message: "Profile has been deleted"}
 	if assert.NoError(t, err) {
 		assert.Equal(t, want, actual)
 	}
@@ -7,15 +7,6 @@ import (
 	"net/http"
 )
 
-type DoType int
-
-const (
-	Block    = 0
-	Bypass   = 1
-	Spoof    = 2
-	Redirect = 3
-)
-
 type ProfileService struct {
 	PK             string   `json:"PK"`
 	Name           string   `json:"name"`
@@ -43,28 +34,22 @@ type ListProfileServicesBody struct {
 	Services []ProfileService `json:"services"`
 }
 
-type ListProfileServicesResponse struct {
-	Body ListProfileServicesBody `json:"body"`
-	Response
-}
+type ListProfileServicesResponse = Envelope[ListProfileServicesBody]
 
 type UpdateProfileServiceParams struct {
-	ProfileID string  `json:"profile_id"`
-	Service   string  `json:"service"`
-	Do        DoType  `json:"do"`
-	Status    IntBool `json:"status"`
-	Via       *string `json:"via"`
-	ViaV6     *string `json:"via_v6"`
+	ProfileID string           `json:"profile_id"`
+	Service   string           `json:"service"`
+	Do        DoType           `json:"do"`
+	Status    IntBool          `json:"status"`
+	Via       Optional[string] `json:"via,omitzero"`
+	ViaV6     Optional[string] `json:"via_v6,omitzero"`
 }
 
 type UpdateProfileServiceBody struct {
 	Services []Action `json:"services"`
 }
 
-type UpdateProfileServiceResponse struct {
-	Body UpdateProfileServiceBody `json:"body"`
-	Response
-}
+type UpdateProfileServiceResponse = Envelope[UpdateProfileServiceBody]
 
 type DeleteProfileServiceParams struct {
 	ProfileID string `json:"profile_id"`
@@ -75,19 +60,16 @@ type DeleteProfileServiceBody struct {
 	Services []Action `json:"services"`
 }
 
-type DeleteProfileServiceResponse struct {
-	Body DeleteProfileServiceBody `json:"body"`
-	Response
-}
+type DeleteProfileServiceResponse = Envelope[DeleteProfileServiceBody]
 
-func (api *API) ListProfileServices(ctx context.Context, params ListProfileServicesParams) ([]ProfileService, error) {
+func (api *API) ListProfileServices(ctx context.Context, params ListProfileServicesParams, opts ...ReqOption) ([]ProfileService, error) {
 	if params.ProfileID == "" {
 		return []ProfileService{}, fmt.Errorf("list: no profile ID provided")
 	}
 	baseURL := fmt.Sprintf("/profiles/%s/services", params.ProfileID)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []ProfileService{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -101,7 +83,7 @@ func (api *API) ListProfileServices(ctx context.Context, params ListProfileServi
 	return r.Body.Services, nil
 }
 
-func (api *API) UpdateProfileService(ctx context.Context, params UpdateProfileServiceParams) ([]Action, error) {
+func (api *API) UpdateProfileService(ctx context.Context, params UpdateProfileServiceParams, opts ...ReqOption) ([]Action, error) {
 	if params.ProfileID == "" {
 		return []Action{}, fmt.Errorf("update: no profile ID provided")
 	}
@@ -111,7 +93,7 @@ func (api *API) UpdateProfileService(ctx context.Context, params UpdateProfileSe
 	baseURL := fmt.Sprintf("/profiles/%s/services/%s", params.ProfileID, params.Service)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params)
+	res, err := api.makeRequestContext(ctx, http.MethodPut, uri, params, opts...)
 	if err != nil {
 		return []Action{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
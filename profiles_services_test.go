@@ -102,8 +102,8 @@ func TestUpdateProfileService(t *testing.T) {
 		Service:   "4chan",
 		Do:        Spoof,
 		Status:    IntBool(true),
-		Via:       &via,
-		ViaV6:     &via,
+		Via:       Set(via),
+		ViaV6:     Set(via),
 	}
 
 	mux.HandleFunc(fmt.Sprintf("/profiles/%s/services/%s", params.ProfileID, params.Service), handler)
@@ -191,7 +191,7 @@ func TestUpdateProfile(t *testing.T) {
 	newProfileName := "New Profile Name"
 	params := UpdateProfileParams{
 		ProfileID: "PK",
-		Name:      &newProfileName,
+		Name:      Set(newProfileName),
 	}
 
 	mux.HandleFunc(fmt.Sprintf("/profiles/%s", params.ProfileID), handler)
@@ -229,8 +229,9 @@ func TestDeleteProfile(t *testing.T) {
 	}
 	profileID := "deviceID"
 	mux.HandleFunc(fmt.Sprintf("/profiles/%s", profileID), handler)
-	_, err := client.DeleteProfile(context.Background(), DeleteProfileParams{ProfileID: profileID})
+	actual, err := client.DeleteProfile(context.Background(), DeleteProfileParams{ProfileID: profileID})
 	require.NoError(t, err, "Profile should have been deleted")
+	assert.Equal(t, DeleteResult{Message: "Profile has been deleted"}, actual)
 
 	_, err = client.DeleteProfile(context.Background(), DeleteProfileParams{ProfileID: ""})
 	require.Error(t, err, "Profile should not have been deleted")
@@ -308,12 +309,11 @@ func TestUpdateProfilesOption(t *testing.T) {
 			}
 		`)
 	}
-	value := "0.9"
 	params := UpdateProfilesOption{
 		ProfileID: "PK",
 		Name:      "ai_malware",
 		Status:    IntBool(true),
-		Value:     &value,
+		Value:     Set("0.9"),
 	}
 
 	mux.HandleFunc(fmt.Sprintf("/profiles/%s/options/%s", params.ProfileID, params.Name), handler)
@@ -327,7 +327,7 @@ func TestUpdateProfilesOption(t *testing.T) {
 		},
 	}
 	if assert.NoError(t, err) {
-		assert.Equal(t, want, actual)
+		assert.Equal(t, UpdateResult{Body: want}, actual)
 	}
 
 	_, err = client.UpdateProfilesOption(context.Background(), UpdateProfilesOption{
@@ -0,0 +1,171 @@
+package controld
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPrometheusBuckets are the histogram buckets, in seconds, used by
+// PrometheusObserver. They match the Prometheus client defaults.
+var defaultPrometheusBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusObserver is an Observer which aggregates API calls into counters
+// and histograms and exposes them in the Prometheus text format. It
+// implements http.Handler so that it can be mounted on a metrics endpoint.
+//
+// The following metrics are exposed:
+//   - controld_requests_total{method,route,code,error_type}
+//   - controld_request_retries_total{method,route}
+//   - controld_request_duration_seconds{method,route}
+//   - controld_rate_limit_wait_seconds{method,route}
+//   - controld_requests_coalesced_total{method,route}
+type PrometheusObserver struct {
+	mu            sync.Mutex
+	requests      map[prometheusLabels]uint64
+	retries       map[prometheusLabels]uint64
+	coalesced     map[prometheusLabels]uint64
+	durations     map[prometheusLabels]*prometheusHistogram
+	rateLimitWait map[prometheusLabels]*prometheusHistogram
+}
+
+// NewPrometheusObserver returns an empty PrometheusObserver.
+func NewPrometheusObserver() *PrometheusObserver {
+	return &PrometheusObserver{
+		requests:      make(map[prometheusLabels]uint64),
+		retries:       make(map[prometheusLabels]uint64),
+		coalesced:     make(map[prometheusLabels]uint64),
+		durations:     make(map[prometheusLabels]*prometheusHistogram),
+		rateLimitWait: make(map[prometheusLabels]*prometheusHistogram),
+	}
+}
+
+// prometheusLabels holds the label values of a series, unused labels are left
+// empty.
+type prometheusLabels struct {
+	method    string
+	route     string
+	code      string
+	errorType string
+}
+
+func (l prometheusLabels) String() string {
+	pairs := []string{
+		fmt.Sprintf(`method="%s"`, escapeLabelValue(l.method)),
+		fmt.Sprintf(`route="%s"`, escapeLabelValue(l.route)),
+	}
+	if l.code != "" {
+		pairs = append(pairs, fmt.Sprintf(`code="%s"`, escapeLabelValue(l.code)))
+		pairs = append(pairs, fmt.Sprintf(`error_type="%s"`, escapeLabelValue(l.errorType)))
+	}
+	return strings.Join(pairs, ",")
+}
+
+type prometheusHistogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *prometheusHistogram) observe(d time.Duration) {
+	v := d.Seconds()
+	for i, bound := range defaultPrometheusBuckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func observeHistogram(histograms map[prometheusLabels]*prometheusHistogram, labels prometheusLabels, d time.Duration) {
+	h, ok := histograms[labels]
+	if !ok {
+		h = &prometheusHistogram{counts: make([]uint64, len(defaultPrometheusBuckets))}
+		histograms[labels] = h
+	}
+	h.observe(d)
+}
+
+// ObserveRequest implements Observer.
+func (o *PrometheusObserver) ObserveRequest(observation RequestObservation) {
+	route := prometheusLabels{method: observation.Method, route: observation.Route}
+	code := route
+	code.code = strconv.Itoa(observation.StatusCode)
+	code.errorType = string(observation.ErrorType)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.requests[code]++
+	if retries := observation.Retries(); retries > 0 {
+		o.retries[route] += uint64(retries)
+	}
+	if observation.Coalesced {
+		o.coalesced[route]++
+	}
+	observeHistogram(o.durations, route, observation.Duration)
+	observeHistogram(o.rateLimitWait, route, observation.RateLimitWait)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (o *PrometheusObserver) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = o.writeMetrics(w)
+}
+
+// writeMetrics writes the metrics in the Prometheus text exposition format to w.
+func (o *PrometheusObserver) writeMetrics(w io.Writer) error {
+	var b strings.Builder
+
+	o.mu.Lock()
+	writeCounter(&b, "controld_requests_total", "Total number of API calls.", o.requests)
+	writeCounter(&b, "controld_request_retries_total", "Total number of retried API call attempts.", o.retries)
+	writeHistogram(&b, "controld_request_duration_seconds", "Duration of API calls, including retries.", o.durations)
+	writeHistogram(&b, "controld_rate_limit_wait_seconds", "Time API calls spent waiting for the rate limiter.", o.rateLimitWait)
+	writeCounter(&b, "controld_requests_coalesced_total", "Total number of API calls which shared the in-flight request of an identical call.", o.coalesced)
+	o.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCounter(b *strings.Builder, name, help string, series map[prometheusLabels]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, labels := range sortedLabels(series) {
+		fmt.Fprintf(b, "%s{%s} %d\n", name, labels, series[labels])
+	}
+}
+
+func writeHistogram(b *strings.Builder, name, help string, series map[prometheusLabels]*prometheusHistogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, labels := range sortedLabels(series) {
+		h := series[labels]
+		for i, bound := range defaultPrometheusBuckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+func sortedLabels[V any](series map[prometheusLabels]V) []prometheusLabels {
+	labels := make([]prometheusLabels, 0, len(series))
+	for l := range series {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].String() < labels[j].String()
+	})
+	return labels
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
@@ -0,0 +1,60 @@
+package controld
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusObserver(t *testing.T) {
+	observer := NewPrometheusObserver()
+	observer.ObserveRequest(RequestObservation{
+		Method:        http.MethodGet,
+		Route:         "/profiles/{id}/rules",
+		StatusCode:    http.StatusOK,
+		Duration:      200 * time.Millisecond,
+		Attempts:      3,
+		RateLimitWait: 20 * time.Millisecond,
+	})
+	observer.ObserveRequest(RequestObservation{
+		Method:     http.MethodGet,
+		Route:      "/profiles/{id}/rules",
+		StatusCode: http.StatusNotFound,
+		ErrorType:  ErrorTypeNotFound,
+		Duration:   2 * time.Second,
+		Attempts:   1,
+	})
+
+	rec := httptest.NewRecorder()
+	observer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	res := rec.Result()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+	metrics := string(body)
+	for _, line := range []string{
+		"# TYPE controld_requests_total counter",
+		`controld_requests_total{method="GET",route="/profiles/{id}/rules",code="200",error_type=""} 1`,
+		`controld_requests_total{method="GET",route="/profiles/{id}/rules",code="404",error_type="not_found"} 1`,
+		`controld_request_retries_total{method="GET",route="/profiles/{id}/rules"} 2`,
+		"# TYPE controld_request_duration_seconds histogram",
+		`controld_request_duration_seconds_bucket{method="GET",route="/profiles/{id}/rules",le="0.25"} 1`,
+		`controld_request_duration_seconds_bucket{method="GET",route="/profiles/{id}/rules",le="2.5"} 2`,
+		`controld_request_duration_seconds_bucket{method="GET",route="/profiles/{id}/rules",le="+Inf"} 2`,
+		`controld_request_duration_seconds_sum{method="GET",route="/profiles/{id}/rules"} 2.2`,
+		`controld_request_duration_seconds_count{method="GET",route="/profiles/{id}/rules"} 2`,
+		`controld_rate_limit_wait_seconds_bucket{method="GET",route="/profiles/{id}/rules",le="0.025"} 2`,
+	} {
+		assert.Contains(t, metrics, line+"\n")
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+}
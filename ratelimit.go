@@ -0,0 +1,249 @@
+package controld
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"weak"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultRateLimit equates to the default API limit (1200 req/5 min).
+	defaultRateLimit = 4
+
+	// defaultRateLimitWindow is the window of the default API limit.
+	defaultRateLimitWindow = 5 * time.Minute
+)
+
+// RateLimiter paces the requests sent by a client. A RateLimiter may be shared
+// by several clients using the same API token so that they spend a single
+// budget, and must be safe for concurrent use.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent or ctx is done.
+	Wait(ctx context.Context) error
+
+	// Observe is called with every response received, including error
+	// responses, so that the limiter can adapt to the server limits.
+	Observe(resp *http.Response)
+
+	// Budget reports the requests left in the current rate limit window.
+	Budget() RateLimitBudget
+}
+
+// RateLimitBudget describes the state of a rate limit window.
+type RateLimitBudget struct {
+	// Limit is the number of requests allowed in the window.
+	Limit int
+
+	// Remaining is the number of requests left in the window.
+	Remaining int
+
+	// Reset is when the window ends and the budget is restored.
+	Reset time.Time
+
+	// Rate is the pace, in requests per second, currently enforced by the
+	// limiter.
+	Rate float64
+}
+
+// AdaptiveRateLimiter is the default RateLimiter. It spaces requests evenly at
+// a configured rate, slows down when the server answers with HTTP 429, and
+// follows the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// headers (or their RateLimit-* equivalents) when the server sends them.
+type AdaptiveRateLimiter struct {
+	limiter *rate.Limiter
+	rps     float64
+	window  time.Duration
+	now     func() time.Time
+
+	mu          sync.Mutex
+	current     float64
+	windowStart time.Time
+	sent        int
+	limit       int
+	remaining   int
+	reset       time.Time
+	fromServer  bool
+}
+
+// NewRateLimiter returns an AdaptiveRateLimiter sending at most rps requests
+// per second.
+func NewRateLimiter(rps float64) *AdaptiveRateLimiter {
+	// because ratelimiter doesnt do any windowing
+	// setting burst makes it difficult to enforce a fixed rate
+	// so setting it equal to 1 this effectively disables bursting
+	// this doesn't check for sensible values, ultimately the api will enforce that the value is ok
+	return &AdaptiveRateLimiter{
+		limiter: rate.NewLimiter(rate.Limit(rps), 1),
+		rps:     rps,
+		current: rps,
+		window:  defaultRateLimitWindow,
+		now:     time.Now,
+	}
+}
+
+// Wait implements RateLimiter.
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	var blocked time.Duration
+	if l.fromServer && l.remaining <= 0 {
+		blocked = l.reset.Sub(l.now())
+	}
+	l.mu.Unlock()
+
+	if blocked > 0 {
+		timer := time.NewTimer(blocked)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := l.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollWindow()
+	l.sent++
+	if l.fromServer && l.remaining > 0 {
+		l.remaining--
+	}
+	return nil
+}
+
+// Observe implements RateLimiter.
+func (l *AdaptiveRateLimiter) Observe(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if limit, remaining, reset, ok := parseRateLimitHeaders(resp.Header, now); ok {
+		l.fromServer = true
+		l.limit = limit
+		l.remaining = remaining
+		l.reset = reset
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// multiplicative decrease, down to a request every few seconds
+		l.current = max(l.current/2, l.rps/16)
+		if l.fromServer {
+			l.remaining = 0
+		}
+	case resp.StatusCode < http.StatusBadRequest:
+		// additive increase back to the configured rate
+		l.current = min(l.current+l.rps/10, l.rps)
+	}
+
+	pace := l.current
+	if l.fromServer && l.remaining > 0 {
+		if untilReset := l.reset.Sub(now).Seconds(); untilReset > 0 {
+			pace = min(pace, float64(l.remaining)/untilReset)
+		}
+	}
+	l.limiter.SetLimit(rate.Limit(pace))
+}
+
+// Budget implements RateLimiter.
+func (l *AdaptiveRateLimiter) Budget() RateLimitBudget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pace := float64(l.limiter.Limit())
+	if l.fromServer && l.reset.After(l.now()) {
+		return RateLimitBudget{Limit: l.limit, Remaining: max(l.remaining, 0), Reset: l.reset, Rate: pace}
+	}
+
+	l.rollWindow()
+	limit := int(l.rps * l.window.Seconds())
+	return RateLimitBudget{
+		Limit:     limit,
+		Remaining: max(limit-l.sent, 0),
+		Reset:     l.windowStart.Add(l.window),
+		Rate:      pace,
+	}
+}
+
+// rollWindow starts a new local accounting window when the current one ended.
+func (l *AdaptiveRateLimiter) rollWindow() {
+	now := l.now()
+	if l.windowStart.IsZero() || !now.Before(l.windowStart.Add(l.window)) {
+		l.windowStart = now
+		l.sent = 0
+	}
+}
+
+// parseRateLimitHeaders reads the rate limit state advertised by the server.
+// The reset header may hold either a delay in seconds or a Unix timestamp.
+func parseRateLimitHeaders(h http.Header, now time.Time) (limit, remaining int, reset time.Time, ok bool) {
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		remainingValue := h.Get(prefix + "Remaining")
+		if remainingValue == "" {
+			continue
+		}
+		remaining, err := strconv.Atoi(remainingValue)
+		if err != nil {
+			continue
+		}
+		limit, _ = strconv.Atoi(h.Get(prefix + "Limit"))
+		reset = now.Add(defaultRateLimitWindow)
+		if seconds, err := strconv.ParseInt(h.Get(prefix+"Reset"), 10, 64); err == nil {
+			if seconds > now.Unix()/2 {
+				reset = time.Unix(seconds, 0)
+			} else {
+				reset = now.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+		return limit, remaining, reset, true
+	}
+	return 0, 0, time.Time{}, false
+}
+
+var (
+	sharedRateLimitersMu sync.Mutex
+	// sharedRateLimiters holds weak pointers, so that the limiter of a token
+	// is dropped once no client uses it anymore, e.g. after the token was
+	// rotated.
+	sharedRateLimiters = make(map[[sha256.Size]byte]weak.Pointer[AdaptiveRateLimiter])
+)
+
+// sharedRateLimiter returns the default rate limiter of token, shared by
+// every client created with it in the process.
+func sharedRateLimiter(token string) *AdaptiveRateLimiter {
+	key := sha256.Sum256([]byte(token))
+
+	sharedRateLimitersMu.Lock()
+	defer sharedRateLimitersMu.Unlock()
+
+	if limiter := sharedRateLimiters[key].Value(); limiter != nil {
+		return limiter
+	}
+	limiter := NewRateLimiter(defaultRateLimit)
+	ref := weak.Make(limiter)
+	sharedRateLimiters[key] = ref
+	runtime.AddCleanup(limiter, func(key [sha256.Size]byte) {
+		sharedRateLimitersMu.Lock()
+		defer sharedRateLimitersMu.Unlock()
+
+		// the key may hold a newer limiter already
+		if sharedRateLimiters[key] == ref {
+			delete(sharedRateLimiters, key)
+		}
+	}, key)
+	return limiter
+}
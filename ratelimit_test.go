@@ -0,0 +1,152 @@
+package controld
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Unix(1716000000, 0)
+
+	h := http.Header{}
+	h.Set("X-RateLimit-Limit", "1200")
+	h.Set("X-RateLimit-Remaining", "42")
+	h.Set("X-RateLimit-Reset", "30")
+	limit, remaining, reset, ok := parseRateLimitHeaders(h, now)
+	require.True(t, ok)
+	assert.Equal(t, 1200, limit)
+	assert.Equal(t, 42, remaining)
+	assert.Equal(t, now.Add(30*time.Second), reset)
+
+	h = http.Header{}
+	h.Set("RateLimit-Remaining", "0")
+	h.Set("RateLimit-Reset", strconv.FormatInt(now.Unix()+60, 10))
+	_, remaining, reset, ok = parseRateLimitHeaders(h, now)
+	require.True(t, ok)
+	assert.Equal(t, 0, remaining)
+	assert.Equal(t, now.Add(time.Minute), reset)
+
+	_, _, _, ok = parseRateLimitHeaders(http.Header{}, now)
+	assert.False(t, ok)
+}
+
+func TestAdaptiveRateLimiterSlowsDownOnTooManyRequests(t *testing.T) {
+	limiter := NewRateLimiter(4)
+
+	limiter.Observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}})
+	assert.Equal(t, 2.0, limiter.Budget().Rate)
+
+	limiter.Observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}})
+	assert.Equal(t, 1.0, limiter.Budget().Rate)
+
+	for i := 0; i < 20; i++ {
+		limiter.Observe(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}})
+	}
+	assert.Equal(t, 4.0, limiter.Budget().Rate, "rate should recover after successful responses")
+}
+
+func TestAdaptiveRateLimiterBudget(t *testing.T) {
+	now := time.Unix(1716000000, 0)
+	limiter := NewRateLimiter(4)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+	budget := limiter.Budget()
+	assert.Equal(t, 1200, budget.Limit)
+	assert.Equal(t, 1197, budget.Remaining)
+	assert.Equal(t, now.Add(5*time.Minute), budget.Reset)
+
+	h := http.Header{}
+	h.Set("X-RateLimit-Limit", "1200")
+	h.Set("X-RateLimit-Remaining", "100")
+	h.Set("X-RateLimit-Reset", "50")
+	limiter.Observe(&http.Response{StatusCode: http.StatusOK, Header: h})
+
+	budget = limiter.Budget()
+	assert.Equal(t, 100, budget.Remaining)
+	assert.Equal(t, now.Add(50*time.Second), budget.Reset)
+	assert.Equal(t, 2.0, budget.Rate, "rate should spread the remaining budget until the reset")
+}
+
+func TestAdaptiveRateLimiterBlocksUntilReset(t *testing.T) {
+	limiter := NewRateLimiter(1000)
+
+	h := http.Header{}
+	h.Set("X-RateLimit-Remaining", "0")
+	h.Set("X-RateLimit-Reset", "1")
+	limiter.Observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: h})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestClientsShareRateLimiterByToken(t *testing.T) {
+	first, err := New("shared-token")
+	require.NoError(t, err)
+	second, err := New("shared-token")
+	require.NoError(t, err)
+	other, err := New("other-token")
+	require.NoError(t, err)
+	own, err := New("shared-token", UsingRateLimit(10))
+	require.NoError(t, err)
+
+	assert.Same(t, first.rateLimiter, second.rateLimiter)
+	assert.NotSame(t, first.rateLimiter, other.rateLimiter)
+	assert.NotSame(t, first.rateLimiter, own.rateLimiter)
+
+	limiter := NewRateLimiter(10)
+	withLimiter, err := New("token", UsingRateLimiter(limiter))
+	require.NoError(t, err)
+	assert.Equal(t, limiter.Budget(), withLimiter.RateLimitBudget())
+}
+
+func TestWithTokenSwitchesRateLimiter(t *testing.T) {
+	client, err := New("first-token")
+	require.NoError(t, err)
+	other, err := New("second-token")
+	require.NoError(t, err)
+
+	derived, err := client.With(APIToken("second-token"))
+	require.NoError(t, err)
+	assert.Same(t, other.rateLimiter, derived.rateLimiter)
+
+	rotated, err := client.With(UsingTokenSource(StaticTokenSource("third-token")))
+	require.NoError(t, err)
+	assert.NotSame(t, client.rateLimiter, rotated.rateLimiter)
+
+	// limiters chosen by the package user are kept
+	limiter := NewRateLimiter(10)
+	own, err := New("first-token", UsingRateLimiter(limiter))
+	require.NoError(t, err)
+	derived, err = own.With(APIToken("second-token"))
+	require.NoError(t, err)
+	assert.Same(t, limiter, derived.rateLimiter)
+}
+
+func TestSharedRateLimiterIsDropped(t *testing.T) {
+	key := sha256.Sum256([]byte("dropped-token"))
+	func() {
+		client, err := New("dropped-token")
+		require.NoError(t, err)
+		require.NotNil(t, client.rateLimiter)
+	}()
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		sharedRateLimitersMu.Lock()
+		defer sharedRateLimitersMu.Unlock()
+		_, ok := sharedRateLimiters[key]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,142 @@
+package controld
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	correlationIDHeader  = "X-Correlation-ID"
+)
+
+// ReqOption is a functional option for configuring API requests.
+type ReqOption func(opt *reqOption)
+
+type reqOption struct {
+	params  url.Values
+	headers http.Header
+	timeout *time.Duration
+	policy  *RetryPolicy
+	dryRun  *bool
+	meta    *ResponseMeta
+}
+
+// newReqOption applies opts in order, later options overriding earlier ones.
+func newReqOption(opts ...ReqOption) *reqOption {
+	ro := &reqOption{
+		params:  make(url.Values),
+		headers: make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(ro)
+	}
+	return ro
+}
+
+// mergeHeaders returns headers with the request option headers added on top.
+func (ro *reqOption) mergeHeaders(headers http.Header) http.Header {
+	if len(ro.headers) == 0 {
+		return headers
+	}
+	merged := make(http.Header)
+	copyHeader(merged, headers)
+	copyHeader(merged, ro.headers)
+	return merged
+}
+
+// retryPolicy returns the retry policy of the request, defaulting to the
+// policy of the client.
+func (ro *reqOption) retryPolicy(policy RetryPolicy) RetryPolicy {
+	if ro.policy != nil {
+		policy = *ro.policy
+	}
+	return policy
+}
+
+// appendQuery adds params to the query of uri.
+func appendQuery(uri string, params url.Values) string {
+	if len(params) == 0 {
+		return uri
+	}
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+	return uri + separator + params.Encode()
+}
+
+// WithHeader sets a header on the request, overriding the client headers.
+func WithHeader(key, value string) ReqOption {
+	return func(opt *reqOption) {
+		opt.headers.Set(key, value)
+	}
+}
+
+// WithQuery adds a query parameter to the request.
+func WithQuery(key, value string) ReqOption {
+	return func(opt *reqOption) {
+		opt.params.Add(key, value)
+	}
+}
+
+// WithTimeout bounds the request, including retries, to timeout, overriding
+// the DefaultTimeout of the client; 0 disables the default timeout for the
+// request.
+func WithTimeout(timeout time.Duration) ReqOption {
+	return func(opt *reqOption) {
+		opt.timeout = &timeout
+	}
+}
+
+// WithRetryPolicy replaces the retry policy of the client for the request.
+func WithRetryPolicy(policy RetryPolicy) ReqOption {
+	return func(opt *reqOption) {
+		opt.policy = &policy
+	}
+}
+
+// WithIdempotencyKey sends key in the Idempotency-Key header. It does not
+// change the retry policy: a POST is only retried if the policy has
+// RetryNonIdempotent set, e.g. with WithRetryPolicy.
+func WithIdempotencyKey(key string) ReqOption {
+	return func(opt *reqOption) {
+		opt.headers.Set(idempotencyKeyHeader, key)
+	}
+}
+
+// WithCorrelationID sends id in the X-Correlation-ID header so that the
+// request can be traced across systems.
+func WithCorrelationID(id string) ReqOption {
+	return func(opt *reqOption) {
+		opt.headers.Set(correlationIDHeader, id)
+	}
+}
+
+// WithOrganization makes the request act on behalf of the sub-organization
+// orgID, overriding the organization set with UsingOrganization.
+func WithOrganization(orgID string) ReqOption {
+	return func(opt *reqOption) {
+		opt.headers.Set(organizationHeader, orgID)
+	}
+}
+
+// WithDryRun records the request as a PlannedOperation instead of sending it
+// when enabled, overriding the DryRun option of the client. Reads are always
+// sent.
+func WithDryRun(enabled bool) ReqOption {
+	return func(opt *reqOption) {
+		opt.dryRun = &enabled
+	}
+}
+
+// WithResponseMeta fills meta with the status code, headers and message of
+// the response when the request succeeds, e.g. to get the confirmation
+// message of an update.
+func WithResponseMeta(meta *ResponseMeta) ReqOption {
+	return func(opt *reqOption) {
+		opt.meta = meta
+	}
+}
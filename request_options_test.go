@@ -0,0 +1,88 @@
+package controld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendQuery(t *testing.T) {
+	params := url.Values{"limit": []string{"10"}}
+	assert.Equal(t, "/devices", appendQuery("/devices", nil))
+	assert.Equal(t, "/devices?limit=10", appendQuery("/devices", params))
+	assert.Equal(t, "/devices?type=os&limit=10", appendQuery("/devices?type=os", params))
+}
+
+func TestRequestOptionsHeadersAndQuery(t *testing.T) {
+	setup(Headers(http.Header{"X-Team": []string{"core"}}))
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ops", r.Header.Get("X-Team"))
+		assert.Equal(t, "correlation", r.Header.Get("X-Correlation-ID"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"devices": []}, "success": true}`)
+	})
+
+	_, err := client.ListDevices(context.Background(),
+		WithHeader("X-Team", "ops"),
+		WithCorrelationID("correlation"),
+		WithQuery("limit", "10"),
+	)
+	require.NoError(t, err)
+}
+
+func TestRequestOptionsTimeout(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	_, err := client.ListDevices(context.Background(), WithTimeout(50*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRequestOptionsRetry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		assert.Equal(t, "create-profile-1", r.Header.Get("Idempotency-Key"))
+		if attempts%2 == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	// the idempotency key alone does not make a POST retryable
+	_, err := client.CreateProfile(context.Background(), CreateProfileParams{Name: "profile"},
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1}),
+		WithIdempotencyKey("create-profile-1"),
+	)
+	require.ErrorIs(t, err, ErrServiceError)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	_, err = client.CreateProfile(context.Background(), CreateProfileParams{Name: "profile"},
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1, RetryNonIdempotent: true}),
+		WithIdempotencyKey("create-profile-1"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts, "a POST should be retried when the policy allows it")
+}
@@ -0,0 +1,128 @@
+package controld
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy specifies number of retries and min/max retry delays
+// This config is used when the client exponentially backs off after errored requests.
+type RetryPolicy struct {
+	MaxRetries    int
+	MinRetryDelay time.Duration
+	// MaxRetryDelay also caps the delay the server may ask for through the
+	// Retry-After header: a request asked to wait longer is not retried.
+	MaxRetryDelay time.Duration
+
+	// RetryNonIdempotent allows requests made with a non-idempotent method
+	// (POST, PATCH) to be retried after a server error or a transport failure.
+	// It is disabled by default as a retried POST may create the same resource
+	// twice. Rate limited (HTTP 429) requests are always retried as the server
+	// did not process them.
+	RetryNonIdempotent bool
+
+	// ShouldRetry, when set, replaces the default retry classification. It is
+	// called after every failed attempt with the request method and either
+	// the response or the transport error.
+	ShouldRetry func(method string, resp *http.Response, err error) bool
+}
+
+// shouldRetry reports whether a failed attempt can be retried under the
+// policy.
+func (p RetryPolicy) shouldRetry(method string, resp *http.Response, err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(method, resp, err)
+	}
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(method) {
+		return false
+	}
+	return DefaultShouldRetry(method, resp, err)
+}
+
+// backoff returns the delay before the given retry attempt (starting at 1).
+// The exponential delay is capped by MaxRetryDelay and fully jittered, unless
+// the server asked for a specific delay through the Retry-After header.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	ceiling := p.MinRetryDelay
+	for i := 1; i < attempt && ceiling < p.MaxRetryDelay; i++ {
+		ceiling *= 2
+	}
+	if ceiling > p.MaxRetryDelay {
+		ceiling = p.MaxRetryDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// retryAfterTooLong reports whether the delay asked for by the server through
+// the Retry-After header is longer than MaxRetryDelay, when set, or than the
+// time left before the deadline of ctx. The request is then not retried.
+func (p RetryPolicy) retryAfterTooLong(ctx context.Context, retryAfter time.Duration) bool {
+	if p.MaxRetryDelay > 0 && retryAfter > p.MaxRetryDelay {
+		return true
+	}
+	if deadline, ok := ctx.Deadline(); ok && retryAfter > time.Until(deadline) {
+		return true
+	}
+	return false
+}
+
+// DefaultShouldRetry is the retry classification used when
+// RetryPolicy.ShouldRetry is not set: transport errors other than context
+// cancellation, HTTP 429 and HTTP 5xx responses are retried.
+func DefaultShouldRetry(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if resp == nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// isIdempotent reports whether a request made with method can safely be sent
+// more than once.
+func isIdempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, expressed either
+// in seconds or as an HTTP-date, and returns the delay it asks for relative
+// to now.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
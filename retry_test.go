@@ -0,0 +1,164 @@
+package controld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "120", 120 * time.Second},
+		{"negative seconds", "-1", 0},
+		{"http date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{"http date in the past", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRetryAfter(tt.value, now))
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MinRetryDelay: time.Second, MaxRetryDelay: 5 * time.Second}
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := policy.backoff(attempt, 0)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, 5*time.Second)
+	}
+	assert.LessOrEqual(t, policy.backoff(1, 0), time.Second)
+	assert.Equal(t, 42*time.Second, policy.backoff(1, 42*time.Second), "Retry-After should override the backoff")
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.backoff(3, 0))
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	serverError := &http.Response{StatusCode: http.StatusBadGateway}
+	tooManyRequests := &http.Response{StatusCode: http.StatusTooManyRequests}
+	notFound := &http.Response{StatusCode: http.StatusNotFound}
+	transportError := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		method string
+		resp   *http.Response
+		err    error
+		want   bool
+	}{
+		{"GET server error", RetryPolicy{}, http.MethodGet, serverError, nil, true},
+		{"PUT server error", RetryPolicy{}, http.MethodPut, serverError, nil, true},
+		{"DELETE transport error", RetryPolicy{}, http.MethodDelete, nil, transportError, true},
+		{"GET not found", RetryPolicy{}, http.MethodGet, notFound, nil, false},
+		{"GET canceled", RetryPolicy{}, http.MethodGet, nil, context.Canceled, false},
+		{"POST server error", RetryPolicy{}, http.MethodPost, serverError, nil, false},
+		{"POST transport error", RetryPolicy{}, http.MethodPost, nil, transportError, false},
+		{"POST rate limited", RetryPolicy{}, http.MethodPost, tooManyRequests, nil, true},
+		{"POST opted in", RetryPolicy{RetryNonIdempotent: true}, http.MethodPost, serverError, nil, true},
+		{"custom hook", RetryPolicy{ShouldRetry: func(string, *http.Response, error) bool { return false }}, http.MethodGet, serverError, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.shouldRetry(tt.method, tt.resp, tt.err))
+		})
+	}
+}
+
+func TestRetryIdempotentRequests(t *testing.T) {
+	setup(UsingRetryPolicy(2, 0, 0))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	_, err := client.ListProfiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryDoesNotRepeatPOST(t *testing.T) {
+	setup(UsingRetryPolicy(2, 0, 0))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := client.CreateProfile(context.Background(), CreateProfileParams{Name: "profile"})
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts, "POST should not be retried by default")
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	setup(UsingRetryPolicy(1, 0, 0))
+	defer teardown()
+
+	var first time.Time
+	var delay time.Duration
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		if first.IsZero() {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		delay = time.Since(first)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	_, err := client.CreateProfile(context.Background(), CreateProfileParams{Name: "profile"})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, delay, time.Second)
+}
+
+func TestRetryAfterLongerThanPolicy(t *testing.T) {
+	setup(UsingRetryPolicy(3, 0, 1))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"success": false, "error": {"message": "Too many requests", "code": 429}}`)
+	})
+
+	start := time.Now()
+	_, err := client.ListDevices(context.Background())
+	var ratelimitErr *RatelimitError
+	require.True(t, errors.As(err, &ratelimitErr), "got %v", err)
+	assert.Equal(t, 1, attempts)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the deadline is too close for the delay asked for
+	attempts = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = client.ListDevices(ctx, WithRetryPolicy(RetryPolicy{MaxRetries: 3}))
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, 1, attempts)
+}
@@ -18,10 +18,7 @@ type ListServiceCategoriesBody struct {
 	Categories []Category `json:"categories"`
 }
 
-type ListServiceCategoriesResponse struct {
-	Body ListServiceCategoriesBody `json:"body"`
-	Response
-}
+type ListServiceCategoriesResponse = Envelope[ListServiceCategoriesBody]
 
 type ListServicesParams struct {
 	Category string `json:"category"`
@@ -39,15 +36,12 @@ type ListServicesBody struct {
 	Services []Service `json:"services"`
 }
 
-type ListServicesResponse struct {
-	Body ListServicesBody `json:"body"`
-	Response
-}
+type ListServicesResponse = Envelope[ListServicesBody]
 
-func (api *API) ListServiceCategories(ctx context.Context) ([]Category, error) {
+func (api *API) ListServiceCategories(ctx context.Context, opts ...ReqOption) ([]Category, error) {
 	uri := buildURI("/services/categories", nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Category{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
@@ -61,14 +55,14 @@ func (api *API) ListServiceCategories(ctx context.Context) ([]Category, error) {
 	return r.Body.Categories, nil
 }
 
-func (api *API) ListServices(ctx context.Context, params ListServicesParams) ([]Service, error) {
+func (api *API) ListServices(ctx context.Context, params ListServicesParams, opts ...ReqOption) ([]Service, error) {
 	if params.Category == "" {
 		return []Service{}, fmt.Errorf("list: no category provided")
 	}
 	baseURL := fmt.Sprintf("/services/categories/%s", params.Category)
 	uri := buildURI(baseURL, nil)
 
-	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil)
+	res, err := api.makeRequestContext(ctx, http.MethodGet, uri, nil, opts...)
 	if err != nil {
 		return []Service{}, fmt.Errorf("%s: %w", errMakeRequestError, err)
 	}
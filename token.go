@@ -0,0 +1,144 @@
+package controld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the API token used to authenticate requests. Token is
+// called before every attempt, so a source may return a rotated token at any
+// time. Implementations must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenRefresher is implemented by token sources which cache the token. When
+// the API rejects a token with HTTP 401, RefreshToken is called to fetch it
+// again before the request is retried once. Sources which do not implement it
+// are asked for a token again with Token.
+type TokenRefresher interface {
+	RefreshToken(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token implements TokenSource.
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticTokenSource returns a TokenSource which always returns token.
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource(token)
+}
+
+type staticTokenSource string
+
+func (s staticTokenSource) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// EnvTokenSource returns a TokenSource reading the token from the environment
+// variable name on every request.
+func EnvTokenSource(name string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		token := strings.TrimSpace(os.Getenv(name))
+		if token == "" {
+			return "", fmt.Errorf("environment variable %s: %s", name, errEmptyAPIToken)
+		}
+		return token, nil
+	})
+}
+
+// FileTokenSource is a TokenSource reading the token from a file, e.g. one
+// mounted by a secrets manager. The file is read again whenever its
+// modification time or size changes, so a rotated token is picked up without
+// restarting the service.
+type FileTokenSource struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileTokenSource returns a FileTokenSource reading the token from path.
+func NewFileTokenSource(path string) *FileTokenSource {
+	return &FileTokenSource{path: path}
+}
+
+// Token implements TokenSource.
+func (s *FileTokenSource) Token(context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("could not read token file: %w", err)
+	}
+	if s.token != "" && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.token, nil
+	}
+	return s.load()
+}
+
+// RefreshToken implements TokenRefresher by reading the file again.
+func (s *FileTokenSource) RefreshToken(context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// load reads the token file. s.mu must be held.
+func (s *FileTokenSource) load() (string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("could not read token file: %w", err)
+	}
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("could not read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("token file %s: %s", s.path, errEmptyAPIToken)
+	}
+	s.token, s.modTime, s.size = token, info.ModTime(), info.Size()
+	return token, nil
+}
+
+// token returns the current API token of the client.
+func (api *API) token(ctx context.Context) (string, error) {
+	token, err := api.tokenSource.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not get API token: %w", err)
+	}
+	if token == "" {
+		return "", errors.New(errEmptyAPIToken)
+	}
+	return token, nil
+}
+
+// refreshToken fetches the API token again after the API rejected token and
+// reports whether a different token is now available.
+func (api *API) refreshToken(ctx context.Context, token string) (string, bool) {
+	var refreshed string
+	var err error
+	if refresher, ok := api.tokenSource.(TokenRefresher); ok {
+		refreshed, err = refresher.RefreshToken(ctx)
+	} else {
+		refreshed, err = api.tokenSource.Token(ctx)
+	}
+	if err != nil || refreshed == "" || refreshed == token {
+		return "", false
+	}
+	return refreshed, true
+}
@@ -0,0 +1,131 @@
+package controld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticTokenSource(t *testing.T) {
+	token, err := StaticTokenSource("api.1377").Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "api.1377", token)
+}
+
+func TestEnvTokenSource(t *testing.T) {
+	source := EnvTokenSource("CONTROLD_TEST_TOKEN")
+
+	t.Setenv("CONTROLD_TEST_TOKEN", "")
+	_, err := source.Token(context.Background())
+	assert.Error(t, err)
+
+	t.Setenv("CONTROLD_TEST_TOKEN", " api.1377\n")
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "api.1377", token)
+}
+
+func TestFileTokenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("api.1377\n"), 0o600))
+
+	source := NewFileTokenSource(path)
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "api.1377", token)
+
+	require.NoError(t, os.WriteFile(path, []byte("api.rotated\n"), 0o600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "api.rotated", token, "a modified file should be read again")
+
+	_, err = NewFileTokenSource(filepath.Join(t.TempDir(), "missing")).Token(context.Background())
+	assert.Error(t, err)
+}
+
+func TestTokenSourceRotation(t *testing.T) {
+	server := setupServer(t)
+
+	var current atomic.Value
+	current.Store("api.1377")
+	api, err := NewWithTokenSource(TokenSourceFunc(func(context.Context) (string, error) {
+		return current.Load().(string), nil
+	}), BaseURL(server.URL), UsingRateLimit(100000), UsingRetryPolicy(0, 0, 0))
+	require.NoError(t, err)
+
+	profiles, err := api.ListProfiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer api.1377", profiles[0].Name)
+
+	current.Store("api.rotated")
+	profiles, err = api.ListProfiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer api.rotated", profiles[0].Name)
+}
+
+func TestUnauthorizedRefreshesTokenOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("api.expired"), 0o600))
+	source := NewFileTokenSource(path)
+	_, err := source.Token(context.Background())
+	require.NoError(t, err)
+
+	// rotate the token without changing the file metadata seen by Token
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("api.renewed"), 0o600))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+
+	setup(UsingTokenSource(source))
+	defer teardown()
+
+	var authorizations []string
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer api.renewed" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"success": false, "error": {"message": "Invalid token", "code": 401}}`)
+			return
+		}
+		fmt.Fprint(w, `{"body": {"profiles": []}, "success": true}`)
+	})
+
+	_, err = client.ListProfiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer api.expired", "Bearer api.renewed"}, authorizations)
+
+	// a static token cannot be refreshed, the 401 is returned as is
+	static, err := client.With(APIToken("api.static"))
+	require.NoError(t, err)
+	authorizations = nil
+	_, err = static.ListProfiles(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, []string{"Bearer api.static"}, authorizations)
+}
+
+// setupServer starts a test server echoing the Authorization header of
+// ListProfiles requests as the profile name.
+func setupServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"body": {"profiles": [{"name": %q}]}, "success": true}`, r.Header.Get("Authorization"))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
@@ -0,0 +1,45 @@
+package controld
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultTimeout bounds calls, including retries, whose context has no
+	// deadline.
+	defaultTimeout = 2 * time.Minute
+
+	defaultDialTimeout           = 10 * time.Second
+	defaultTLSHandshakeTimeout   = 10 * time.Second
+	defaultResponseHeaderTimeout = 30 * time.Second
+	defaultIdleConnTimeout       = 90 * time.Second
+)
+
+// defaultHTTPClient is used by clients which are not given their own
+// *http.Client, so that they share a single connection pool.
+var defaultHTTPClient = &http.Client{Transport: newDefaultTransport()}
+
+// newDefaultTransport returns a transport which, unlike
+// http.DefaultTransport, cannot hang forever on a stuck connection: dialing,
+// the TLS handshake and waiting for the response headers are all bounded.
+// Connections are kept alive and pooled, and HTTP/2 is used when the server
+// supports it.
+func newDefaultTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       defaultIdleConnTimeout,
+		TLSHandshakeTimeout:   defaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: defaultResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
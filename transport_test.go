@@ -0,0 +1,76 @@
+package controld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultHTTPClient(t *testing.T) {
+	api, err := New("api.1377")
+	require.NoError(t, err)
+	assert.NotSame(t, http.DefaultClient, api.httpClient)
+	assert.Same(t, defaultHTTPClient, api.httpClient)
+
+	transport := api.httpClient.Transport.(*http.Transport)
+	assert.Equal(t, defaultTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	assert.Equal(t, defaultResponseHeaderTimeout, transport.ResponseHeaderTimeout)
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Positive(t, transport.MaxIdleConnsPerHost)
+}
+
+// slowHandler answers after delay, or gives up when the client does.
+func slowHandler(delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"body": {"devices": []}, "success": true}`)
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	setup(DefaultTimeout(20 * time.Millisecond))
+	defer teardown()
+	mux.HandleFunc("/devices", slowHandler(time.Second))
+
+	start := time.Now()
+	_, err := client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestDefaultTimeoutOverrides(t *testing.T) {
+	setup(DefaultTimeout(20 * time.Millisecond))
+	defer teardown()
+	mux.HandleFunc("/devices", slowHandler(100*time.Millisecond))
+
+	_, err := client.ListDevices(context.Background(), WithTimeout(time.Second))
+	assert.NoError(t, err, "WithTimeout should override the default timeout")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.ListDevices(ctx)
+	assert.NoError(t, err, "the default timeout should not apply to contexts with a deadline")
+
+	noDefault, err := client.With(DefaultTimeout(0))
+	require.NoError(t, err)
+	_, err = noDefault.ListDevices(context.Background())
+	assert.NoError(t, err)
+
+	_, err = client.ListDevices(context.Background(), WithTimeout(0))
+	assert.NoError(t, err, "WithTimeout(0) should disable the default timeout")
+}
+
+func TestDefaultTimeoutValidation(t *testing.T) {
+	_, err := New("api.1377", DefaultTimeout(-time.Second))
+	assert.Error(t, err)
+}
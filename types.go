@@ -0,0 +1,270 @@
+package controld
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types below decode every representation the API has been seen to emit
+// for a value. JSON null leaves them untouched, as for the standard library
+// types, and their zero value encodes as null so that it decodes back to the
+// zero value.
+
+var (
+	dateTimeLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339Nano, time.DateTime, time.DateOnly}
+	dateLayouts     = []string{time.DateOnly, time.RFC3339Nano, time.DateTime}
+)
+
+// jsonText returns the content of the JSON string data, or data itself for
+// the other JSON values. null reports whether data is the JSON null.
+func jsonText(data []byte) (text string, null bool, err error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case string(data) == "null":
+		return "", true, nil
+	case len(data) > 0 && data[0] == '"':
+		err = json.Unmarshal(data, &text)
+		return text, false, err
+	default:
+		return string(data), false, nil
+	}
+}
+
+// parseTimeLayouts parses value with the first matching layout.
+func parseTimeLayouts(value string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", value)
+}
+
+// DateTime is a time encoded as a date and time string, e.g. RFC1123Z or
+// RFC3339. It is encoded as RFC3339 with nanoseconds.
+type DateTime struct {
+	time.Time
+}
+
+func (dt DateTime) MarshalJSON() ([]byte, error) {
+	if dt.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(dt.Format(time.RFC3339Nano))
+}
+
+func (dt *DateTime) UnmarshalJSON(data []byte) error {
+	text, null, err := jsonText(data)
+	if err != nil || null {
+		return err
+	}
+	return dt.UnmarshalText([]byte(text))
+}
+
+func (dt DateTime) MarshalText() ([]byte, error) {
+	if dt.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(dt.Format(time.RFC3339Nano)), nil
+}
+
+func (dt *DateTime) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		dt.Time = time.Time{}
+		return nil
+	}
+	t, err := parseTimeLayouts(string(text), dateTimeLayouts)
+	if err != nil {
+		return err
+	}
+	dt.Time = t
+	return nil
+}
+
+// Date is a calendar date encoded as YYYY-MM-DD. Decoded dates are at
+// midnight UTC; a date with a time of day only keeps its date.
+type Date struct {
+	time.Time
+}
+
+func (s Date) MarshalJSON() ([]byte, error) {
+	if s.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(s.Format(time.DateOnly))
+}
+
+func (s *Date) UnmarshalJSON(data []byte) error {
+	text, null, err := jsonText(data)
+	if err != nil || null {
+		return err
+	}
+	return s.UnmarshalText([]byte(text))
+}
+
+func (s Date) MarshalText() ([]byte, error) {
+	if s.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(s.Format(time.DateOnly)), nil
+}
+
+func (s *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		s.Time = time.Time{}
+		return nil
+	}
+	t, err := parseTimeLayouts(string(text), dateLayouts)
+	if err != nil {
+		return err
+	}
+	year, month, day := t.Date()
+	s.Time = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+// UnixTime is a time encoded as seconds since the Unix epoch, either as a JSON
+// number or a string, with an optional decimal part. Decoded times are in UTC.
+type UnixTime struct {
+	time.Time
+}
+
+func (s UnixTime) MarshalJSON() ([]byte, error) {
+	if s.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(formatUnixTime(s.Time)), nil
+}
+
+func (s *UnixTime) UnmarshalJSON(data []byte) error {
+	text, null, err := jsonText(data)
+	if err != nil || null {
+		return err
+	}
+	return s.UnmarshalText([]byte(text))
+}
+
+func (s UnixTime) MarshalText() ([]byte, error) {
+	if s.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(formatUnixTime(s.Time)), nil
+}
+
+func (s *UnixTime) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		s.Time = time.Time{}
+		return nil
+	}
+	t, err := parseUnixTime(string(text))
+	if err != nil {
+		return err
+	}
+	s.Time = t
+	return nil
+}
+
+// formatUnixTime formats t as decimal seconds since the Unix epoch, without
+// trailing zeros.
+func formatUnixTime(t time.Time) string {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	if nsec == 0 {
+		return strconv.FormatInt(sec, 10)
+	}
+	sign := ""
+	if sec < 0 {
+		// -1.5 is 2 seconds before the epoch plus 500ms
+		sign, sec, nsec = "-", -(sec + 1), 1e9-nsec
+	}
+	return fmt.Sprintf("%s%d.%s", sign, sec, strings.TrimRight(fmt.Sprintf("%09d", nsec), "0"))
+}
+
+// parseUnixTime parses decimal seconds since the Unix epoch, keeping up to
+// nanosecond precision.
+func parseUnixTime(value string) (time.Time, error) {
+	invalid := fmt.Errorf("cannot parse %q as a unix time", value)
+
+	digits, negative := value, false
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits, negative = digits[1:], digits[0] == '-'
+	}
+
+	var sec, nsec int64
+	if strings.ContainsAny(digits, "eE") {
+		// exponent notation does not need nanosecond precision
+		f, err := strconv.ParseFloat(digits, 64)
+		if err != nil || f < 0 || f >= math.MaxInt64 {
+			return time.Time{}, invalid
+		}
+		whole, frac := math.Modf(f)
+		sec, nsec = int64(whole), int64(frac*1e9)
+	} else {
+		whole, frac, hasFrac := strings.Cut(digits, ".")
+		s, err := strconv.ParseUint(whole, 10, 63)
+		if err != nil {
+			return time.Time{}, invalid
+		}
+		sec = int64(s)
+		if hasFrac {
+			if frac == "" || strings.Trim(frac, "0123456789") != "" {
+				return time.Time{}, invalid
+			}
+			frac = (frac + "000000000")[:9]
+			n, _ := strconv.ParseInt(frac, 10, 64)
+			nsec = n
+		}
+	}
+	if negative {
+		sec, nsec = -sec, -nsec
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+// IntBool is a boolean encoded as 1 or 0. It also decodes JSON booleans,
+// any other integer (non-zero is true) and strings holding any of these.
+type IntBool bool
+
+func (s IntBool) MarshalJSON() ([]byte, error) {
+	if s {
+		return json.Marshal(1)
+	} else {
+		return json.Marshal(0)
+	}
+}
+
+func (s *IntBool) UnmarshalJSON(data []byte) error {
+	text, null, err := jsonText(data)
+	if err != nil || null {
+		return err
+	}
+	return s.UnmarshalText([]byte(text))
+}
+
+func (s IntBool) MarshalText() ([]byte, error) {
+	if s {
+		return []byte("1"), nil
+	}
+	return []byte("0"), nil
+}
+
+func (s *IntBool) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = false
+		return nil
+	}
+	if b, err := strconv.ParseBool(string(text)); err == nil {
+		*s = IntBool(b)
+		return nil
+	}
+	value, err := strconv.ParseInt(string(text), 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse %q as a boolean", text)
+	}
+	*s = IntBool(value != 0)
+	return nil
+}
@@ -0,0 +1,177 @@
+package controld
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateTimeJSON(t *testing.T) {
+	want := time.Date(2024, 11, 10, 10, 45, 25, 0, time.UTC)
+	for _, input := range []string{
+		`"Sun, 10 Nov 2024 10:45:25 +0000"`,
+		`"Sun, 10 Nov 2024 10:45:25 UTC"`,
+		`"2024-11-10T10:45:25Z"`,
+		`"2024-11-10T11:45:25+01:00"`,
+		`"2024-11-10 10:45:25"`,
+	} {
+		var dt DateTime
+		require.NoError(t, json.Unmarshal([]byte(input), &dt), input)
+		assert.True(t, want.Equal(dt.Time), input)
+	}
+
+	data, err := json.Marshal(DateTime{want.Add(123 * time.Millisecond)})
+	require.NoError(t, err)
+	assert.Equal(t, `"2024-11-10T10:45:25.123Z"`, string(data))
+
+	var dt DateTime
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &dt))
+	assert.Error(t, json.Unmarshal([]byte(`1`), &dt))
+}
+
+func TestDateJSON(t *testing.T) {
+	want := time.Date(2024, 11, 10, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{`"2024-11-10"`, `"2024-11-10T23:45:25+05:00"`, `"2024-11-10 10:45:25"`} {
+		var d Date
+		require.NoError(t, json.Unmarshal([]byte(input), &d), input)
+		assert.Equal(t, want, d.Time, input)
+	}
+
+	data, err := json.Marshal(Date{want})
+	require.NoError(t, err)
+	assert.Equal(t, `"2024-11-10"`, string(data))
+}
+
+func TestUnixTimeJSON(t *testing.T) {
+	tests := map[string]time.Time{
+		`1731235525`:             time.Unix(1731235525, 0),
+		`"1731235525"`:           time.Unix(1731235525, 0),
+		`1731235525.5`:           time.Unix(1731235525, 5e8),
+		`"1731235525.123456789"`: time.Unix(1731235525, 123456789),
+		`1.731235525e9`:          time.Unix(1731235525, 0),
+		`0`:                      time.Unix(0, 0),
+		`-1.5`:                   time.Unix(-2, 5e8),
+	}
+	for input, want := range tests {
+		var ut UnixTime
+		require.NoError(t, json.Unmarshal([]byte(input), &ut), input)
+		assert.Equal(t, want.UTC(), ut.Time, input)
+	}
+
+	for _, input := range []string{`"soon"`, `1.`, `.5`, `"1e400"`, `true`} {
+		var ut UnixTime
+		assert.Error(t, json.Unmarshal([]byte(input), &ut), input)
+	}
+
+	data, err := json.Marshal(UnixTime{time.Unix(1731235525, 120000000)})
+	require.NoError(t, err)
+	assert.Equal(t, `1731235525.12`, string(data))
+}
+
+func TestIntBoolJSON(t *testing.T) {
+	tests := map[string]IntBool{
+		`1`: true, `0`: false, `2`: true,
+		`true`: true, `false`: false,
+		`"1"`: true, `"0"`: false, `"true"`: true, `""`: false,
+	}
+	for input, want := range tests {
+		var b IntBool
+		require.NoError(t, json.Unmarshal([]byte(input), &b), input)
+		assert.Equal(t, want, b, input)
+	}
+
+	var b IntBool
+	assert.Error(t, json.Unmarshal([]byte(`"yes"`), &b))
+}
+
+func TestCustomTypesNull(t *testing.T) {
+	var v struct {
+		DateTime DateTime `json:"date_time"`
+		Date     Date     `json:"date"`
+		UnixTime UnixTime `json:"unix_time"`
+		IntBool  IntBool  `json:"int_bool"`
+	}
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"date_time": null, "date": null, "unix_time": null, "int_bool": 0}`, string(data))
+
+	v.UnixTime = UnixTime{time.Unix(1731235525, 0)}
+	v.IntBool = true
+	require.NoError(t, json.Unmarshal([]byte(`{"date_time": null, "date": null, "unix_time": null, "int_bool": null}`), &v))
+	assert.Equal(t, int64(1731235525), v.UnixTime.Unix(), "null should leave the value untouched")
+	assert.True(t, bool(v.IntBool))
+}
+
+func TestCustomTypesText(t *testing.T) {
+	ut := UnixTime{time.Unix(1731235525, 5e8)}
+	text, err := ut.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "1731235525.5", string(text))
+
+	var parsed UnixTime
+	require.NoError(t, parsed.UnmarshalText(text))
+	assert.True(t, ut.Equal(parsed.Time))
+
+	text, err = Date{time.Date(2024, 11, 10, 0, 0, 0, 0, time.UTC)}.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "2024-11-10", string(text))
+
+	text, err = IntBool(true).MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(text))
+}
+
+// fuzzRoundTrip checks that whatever decodes into T encodes to valid JSON
+// which decodes back to an equal value and encodes the same way.
+func fuzzRoundTrip[T any](f *testing.F, seeds []string, equal func(a, b T) bool) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		var first T
+		if json.Unmarshal([]byte(input), &first) != nil {
+			return
+		}
+		data, err := json.Marshal(first)
+		require.NoError(t, err)
+		require.True(t, json.Valid(data), "invalid JSON %s", data)
+
+		var second T
+		require.NoError(t, json.Unmarshal(data, &second), "cannot decode %s", data)
+		require.True(t, equal(first, second), "%s decoded as %v then %v", input, first, second)
+
+		again, err := json.Marshal(second)
+		require.NoError(t, err)
+		require.Equal(t, string(data), string(again))
+
+		text, err := any(&first).(interface{ MarshalText() ([]byte, error) }).MarshalText()
+		require.NoError(t, err)
+		var third T
+		require.NoError(t, any(&third).(interface{ UnmarshalText([]byte) error }).UnmarshalText(text), "cannot decode text %q", text)
+		require.True(t, equal(first, third), "text %q decoded as %v", text, third)
+	})
+}
+
+func FuzzDateTime(f *testing.F) {
+	fuzzRoundTrip(f, []string{
+		`"Sun, 10 Nov 2024 10:45:25 +0000"`, `"2024-11-10T10:45:25.123+01:00"`, `"2024-11-10 10:45:25"`, `"2024-11-10"`, `null`, `""`,
+	}, func(a, b DateTime) bool { return a.Equal(b.Time) })
+}
+
+func FuzzDate(f *testing.F) {
+	fuzzRoundTrip(f, []string{`"2024-11-10"`, `"2024-11-10T23:45:25+05:00"`, `null`, `""`},
+		func(a, b Date) bool { return a.Equal(b.Time) })
+}
+
+func FuzzUnixTime(f *testing.F) {
+	fuzzRoundTrip(f, []string{`1731235525`, `"1731235525"`, `1731235525.123456789`, `-1.5`, `1.7e9`, `0`, `null`, `""`},
+		func(a, b UnixTime) bool { return a.Equal(b.Time) })
+}
+
+func FuzzIntBool(f *testing.F) {
+	fuzzRoundTrip(f, []string{`1`, `0`, `true`, `"1"`, `"false"`, `null`, `""`},
+		func(a, b IntBool) bool { return a == b })
+}